@@ -0,0 +1,83 @@
+// Command fake-lambda-api serves the in-memory Lambda Cloud API stand-in so
+// that configurations, such as the ones in examples/, can be applied locally
+// without an account.
+//
+//	go run ./cmd/fake-lambda-api -addr 127.0.0.1:8080 -ssh-keys my-key
+//
+// Point the provider at it with endpoint = "http://127.0.0.1:8080". Faults
+// and capacity can be changed while it runs through the /_fake/ endpoints,
+// for example:
+//
+//	curl -X POST localhost:8080/_fake/faults \
+//	  -d '{"path_prefix": "/api/v1/instance-operations/launch", "status_code": 400,
+//	       "code": "instance-operations/launch/insufficient-capacity", "times": 1}'
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/albertocavalcante/terraform-provider-lambda/internal/fakelambda"
+)
+
+func main() {
+	var addr, sshKeys string
+	var opts fakelambda.Options
+
+	flag.StringVar(&addr, "addr", "127.0.0.1:8080", "address to listen on")
+	flag.StringVar(&opts.APIKey, "api-key", "", "API key clients must send; any key is accepted when empty")
+	flag.DurationVar(&opts.BootDuration, "boot-duration", 10*time.Second, "how long launched instances stay booting")
+	flag.DurationVar(&opts.RebootDuration, "reboot-duration", 5*time.Second, "how long restarted instances stay booting")
+	flag.DurationVar(&opts.TerminateDuration, "terminate-duration", 5*time.Second, "how long terminated instances stay terminating")
+	flag.StringVar(&sshKeys, "ssh-keys", "", "comma-separated names of SSH keys the account starts with")
+	flag.Parse()
+
+	fake := fakelambda.New(opts)
+
+	for _, name := range strings.Split(sshKeys, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if _, err := fake.AddSshKey(name); err != nil {
+			log.Fatal(err.Error())
+		}
+	}
+
+	server := &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			fake.ServeHTTP(w, r)
+			log.Printf("%s %s (%s)", r.Method, r.URL.Path, time.Since(start).Round(time.Millisecond))
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("shutdown: %s", err)
+		}
+	}()
+
+	log.Printf("fake Lambda Cloud API listening on http://%s", addr)
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err.Error())
+	}
+}
@@ -17,6 +17,10 @@ provider "lambda" {
 
   # endpoint defaults to https://cloud.lambda.ai
   # endpoint = "https://cloud.lambda.ai"
+
+  # To try the examples locally, run `go run ./cmd/fake-lambda-api -ssh-keys my-key`
+  # endpoint            = "http://127.0.0.1:8080"
+  # allow_insecure_http = true
 }
 
 # Example: Query available instance types
@@ -0,0 +1,79 @@
+package fakelambda
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// adminPathPrefix is where the endpoints that control a running server live.
+const adminPathPrefix = "/_fake/"
+
+// adminFault is the JSON form of a Fault, with durations written as strings
+// such as "2s".
+type adminFault struct {
+	Fault
+	Latency    string `json:"latency,omitempty"`
+	RetryAfter string `json:"retry_after,omitempty"`
+}
+
+// serveAdmin handles the admin endpoints:
+//
+//	POST   /_fake/faults    inject a fault
+//	DELETE /_fake/faults    remove every fault
+//	PUT    /_fake/capacity  set capacity for an instance type and region
+//	GET    /_fake/requests  list the API requests received
+func (s *Server) serveAdmin(w http.ResponseWriter, r *http.Request) {
+	switch r.Method + " " + r.URL.Path {
+	case "POST /_fake/faults":
+		var req adminFault
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		fault := req.Fault
+		for _, d := range []struct {
+			value  string
+			target *time.Duration
+		}{{req.Latency, &fault.Latency}, {req.RetryAfter, &fault.RetryAfter}} {
+			if d.value == "" {
+				continue
+			}
+			parsed, err := time.ParseDuration(d.value)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			*d.target = parsed
+		}
+
+		s.InjectFault(fault)
+		w.WriteHeader(http.StatusNoContent)
+
+	case "DELETE /_fake/faults":
+		s.ClearFaults()
+		w.WriteHeader(http.StatusNoContent)
+
+	case "PUT /_fake/capacity":
+		var req struct {
+			InstanceTypeName string `json:"instance_type_name"`
+			RegionName       string `json:"region_name"`
+			Available        int    `json:"available"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		s.SetCapacity(req.InstanceTypeName, req.RegionName, req.Available)
+		w.WriteHeader(http.StatusNoContent)
+
+	case "GET /_fake/requests":
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(s.Requests())
+
+	default:
+		http.NotFound(w, r)
+	}
+}
@@ -0,0 +1,137 @@
+package fakelambda
+
+import (
+	"net/http"
+	"sort"
+)
+
+// defaultCapacity is how many instances of each type can be launched in each
+// region that offers it.
+const defaultCapacity = 10
+
+// Region is a Lambda Cloud region.
+type Region struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// InstanceType describes an instance type in the catalog.
+type InstanceType struct {
+	Name              string            `json:"name"`
+	Description       string            `json:"description"`
+	GpuDescription    string            `json:"gpu_description"`
+	PriceCentsPerHour int64             `json:"price_cents_per_hour"`
+	Specs             InstanceTypeSpecs `json:"specs"`
+}
+
+// InstanceTypeSpecs describes the hardware of an instance type.
+type InstanceTypeSpecs struct {
+	Vcpus      int64 `json:"vcpus"`
+	MemoryGib  int64 `json:"memory_gib"`
+	StorageGib int64 `json:"storage_gib"`
+	Gpus       int64 `json:"gpus"`
+}
+
+var regionDescriptions = map[string]string{
+	"us-east-1":        "Virginia, USA",
+	"us-east-2":        "Washington DC, USA",
+	"us-east-3":        "Georgia, USA",
+	"us-west-1":        "California, USA",
+	"us-west-2":        "Arizona, USA",
+	"us-west-3":        "Utah, USA",
+	"us-south-1":       "Texas, USA",
+	"us-south-2":       "North Texas, USA",
+	"us-south-3":       "Central Texas, USA",
+	"us-midwest-1":     "Illinois, USA",
+	"us-midwest-2":     "Iowa, USA",
+	"europe-central-1": "Germany",
+	"asia-south-1":     "India",
+	"asia-northeast-1": "Osaka, Japan",
+	"asia-northeast-2": "Seoul, South Korea",
+	"australia-east-1": "Sydney, Australia",
+	"me-west-1":        "Israel",
+	"test-east-1":      "Test East",
+	"test-west-1":      "Test West",
+}
+
+// regionFor returns the region with the given name, or false if there is none.
+func regionFor(name string) (Region, bool) {
+	description, ok := regionDescriptions[name]
+	return Region{Name: name, Description: description}, ok
+}
+
+type catalogEntry struct {
+	instanceType InstanceType
+	regions      []string
+}
+
+func defaultCatalog() []catalogEntry {
+	gpu := func(name, gpuDescription string, price, vcpus, memory, storage, gpus int64, regions ...string) catalogEntry {
+		return catalogEntry{
+			instanceType: InstanceType{
+				Name:              name,
+				Description:       gpuDescription,
+				GpuDescription:    gpuDescription,
+				PriceCentsPerHour: price,
+				Specs:             InstanceTypeSpecs{Vcpus: vcpus, MemoryGib: memory, StorageGib: storage, Gpus: gpus},
+			},
+			regions: regions,
+		}
+	}
+
+	return []catalogEntry{
+		gpu("gpu_1x_a10", "1x A10 (24 GB PCIe)", 75, 30, 200, 1400, 1, "us-east-1", "us-west-1", "test-east-1", "test-west-1"),
+		gpu("gpu_1x_a6000", "1x RTX A6000 (48 GB)", 80, 14, 46, 512, 1, "us-south-1", "test-east-1"),
+		gpu("gpu_1x_a100_sxm4", "1x A100 (40 GB SXM4)", 129, 30, 200, 512, 1, "us-east-1", "test-east-1"),
+		gpu("gpu_8x_a100_80gb_sxm4", "8x A100 (80 GB SXM4)", 1432, 240, 1800, 20480, 8, "us-east-1"),
+		gpu("gpu_1x_h100_pcie", "1x H100 (80 GB PCIe)", 249, 26, 200, 1024, 1, "us-west-3", "test-west-1"),
+		gpu("gpu_8x_h100_sxm5", "8x H100 (80 GB SXM5)", 2392, 208, 1800, 24780, 8, "us-south-2"),
+		gpu("gpu_1x_gh200", "1x GH200 (96 GB)", 149, 64, 432, 4096, 1, "us-east-3", "test-east-1"),
+		gpu("gpu_8x_b200_sxm6", "8x B200 (180 GB SXM6)", 3992, 208, 2900, 22000, 8, "us-south-3"),
+	}
+}
+
+// SetCapacity sets how many more instances of a type can be launched in a
+// region. Zero removes the region from the type's available regions.
+func (s *Server) SetCapacity(instanceTypeName, regionName string, available int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.capacity[instanceTypeName] == nil {
+		s.capacity[instanceTypeName] = make(map[string]int)
+	}
+	s.capacity[instanceTypeName][regionName] = available
+}
+
+type instanceTypeEntry struct {
+	InstanceType                 InstanceType `json:"instance_type"`
+	RegionsWithCapacityAvailable []Region     `json:"regions_with_capacity_available"`
+}
+
+func (s *Server) listInstanceTypes(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data := make(map[string]instanceTypeEntry, len(s.instanceTypes))
+	for name, instanceType := range s.instanceTypes {
+		regions := []Region{}
+		for _, regionName := range sortedRegionNames(s.capacity[name]) {
+			if s.capacity[name][regionName] > 0 {
+				region, _ := regionFor(regionName)
+				regions = append(regions, region)
+			}
+		}
+		data[name] = instanceTypeEntry{InstanceType: instanceType, RegionsWithCapacityAvailable: regions}
+	}
+
+	writeData(w, data)
+}
+
+func sortedRegionNames(capacity map[string]int) []string {
+	names := make([]string, 0, len(capacity))
+	for name := range capacity {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
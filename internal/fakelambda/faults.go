@@ -0,0 +1,145 @@
+package fakelambda
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fault describes a failure or delay injected into matching requests.
+type Fault struct {
+	// Method and PathPrefix select the requests the fault applies to. Empty
+	// values match every request.
+	Method     string `json:"method,omitempty"`
+	PathPrefix string `json:"path_prefix,omitempty"`
+
+	// StatusCode, Code and Message make up the error response. When
+	// StatusCode is zero the request is only delayed and then served.
+	StatusCode int    `json:"status_code,omitempty"`
+	Code       string `json:"code,omitempty"`
+	Message    string `json:"message,omitempty"`
+
+	// Latency delays the response.
+	Latency time.Duration `json:"latency,omitempty"`
+
+	// RetryAfter sets the Retry-After header of the error response.
+	RetryAfter time.Duration `json:"retry_after,omitempty"`
+
+	// Times is how many matching requests the fault applies to before it is
+	// removed. Zero applies it until ClearFaults is called.
+	Times int `json:"times,omitempty"`
+}
+
+// CapacityFault fails the next launches with an insufficient capacity error,
+// regardless of the capacity set with SetCapacity.
+func CapacityFault(times int) Fault {
+	return Fault{
+		Method:     http.MethodPost,
+		PathPrefix: "/api/v1/instance-operations/launch",
+		StatusCode: http.StatusBadRequest,
+		Code:       ErrorCodeInsufficientCapacity,
+		Message:    "Not enough capacity to fulfill launch request.",
+		Times:      times,
+	}
+}
+
+// RateLimitFault answers the next requests with 429 Too Many Requests.
+func RateLimitFault(times int, retryAfter time.Duration) Fault {
+	return Fault{
+		StatusCode: http.StatusTooManyRequests,
+		Code:       ErrorCodeTooManyRequests,
+		Message:    "Too many requests.",
+		RetryAfter: retryAfter,
+		Times:      times,
+	}
+}
+
+// ServerErrorFault answers the next requests with the given 5xx status.
+func ServerErrorFault(times, statusCode int) Fault {
+	return Fault{
+		StatusCode: statusCode,
+		Code:       ErrorCodeInternal,
+		Message:    "Internal server error.",
+		Times:      times,
+	}
+}
+
+// LatencyFault delays every request by latency.
+func LatencyFault(latency time.Duration) Fault {
+	return Fault{Latency: latency}
+}
+
+// InjectFault adds a fault. Faults are checked in the order they were added
+// and the first match applies.
+func (s *Server) InjectFault(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &fault)
+}
+
+// ClearFaults removes every injected fault.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// takeFault returns the first fault matching r, counting it against the
+// fault's Times.
+func (s *Server) takeFault(r *http.Request) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, fault := range s.faults {
+		if fault.Method != "" && fault.Method != r.Method {
+			continue
+		}
+		if !strings.HasPrefix(r.URL.Path, fault.PathPrefix) {
+			continue
+		}
+
+		matched := *fault
+		if fault.Times > 0 {
+			fault.Times--
+			if fault.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return &matched
+	}
+
+	return nil
+}
+
+// applyFault applies the fault matching r, if any. It returns true if it
+// wrote a response.
+func (s *Server) applyFault(w http.ResponseWriter, r *http.Request) bool {
+	fault := s.takeFault(r)
+	if fault == nil {
+		return false
+	}
+
+	if fault.Latency > 0 {
+		timer := time.NewTimer(fault.Latency)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			return true
+		}
+	}
+
+	if fault.StatusCode == 0 {
+		return false
+	}
+
+	if fault.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int((fault.RetryAfter+time.Second-1)/time.Second)))
+	}
+	writeError(w, fault.StatusCode, fault.Code, fault.Message, "")
+	return true
+}
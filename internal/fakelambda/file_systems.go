@@ -0,0 +1,116 @@
+package fakelambda
+
+import (
+	"net/http"
+	"time"
+)
+
+// FileSystem is a persistent file system as returned by the API.
+type FileSystem struct {
+	Id         string    `json:"id"`
+	Name       string    `json:"name"`
+	MountPoint string    `json:"mount_point"`
+	Created    time.Time `json:"created"`
+	IsInUse    bool      `json:"is_in_use"`
+	Region     Region    `json:"region"`
+	BytesUsed  int64     `json:"bytes_used"`
+}
+
+// findFileSystem returns the file system with the given name. The caller must
+// hold s.mu.
+func (s *Server) findFileSystem(name string) *FileSystem {
+	for i := range s.fileSystems {
+		if s.fileSystems[i].Name == name {
+			return &s.fileSystems[i]
+		}
+	}
+	return nil
+}
+
+// fileSystemInUse reports whether a running instance mounts the file system.
+// The caller must hold s.mu.
+func (s *Server) fileSystemInUse(name string, now time.Time) bool {
+	for _, inst := range s.listLocked(now) {
+		for _, mounted := range inst.FileSystemNames {
+			if mounted == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Server) listFileSystems(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.opts.Now()
+	fileSystems := make([]FileSystem, 0, len(s.fileSystems))
+	for _, fileSystem := range s.fileSystems {
+		fileSystem.IsInUse = s.fileSystemInUse(fileSystem.Name, now)
+		fileSystems = append(fileSystems, fileSystem)
+	}
+
+	writeData(w, fileSystems)
+}
+
+func (s *Server) createFileSystem(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name   string `json:"name"`
+		Region string `json:"region"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	region, ok := regionFor(req.Region)
+	if !ok {
+		writeError(w, http.StatusBadRequest, ErrorCodeInvalidParameters, "Unknown region "+req.Region, "")
+		return
+	}
+	if req.Name == "" || len(req.Name) > 60 {
+		writeError(w, http.StatusBadRequest, ErrorCodeInvalidParameters, "name must be between 1 and 60 characters", "")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findFileSystem(req.Name) != nil {
+		writeError(w, http.StatusBadRequest, ErrorCodeDuplicate, "File system with name "+req.Name+" already exists", "")
+		return
+	}
+
+	fileSystem := FileSystem{
+		Id:         newId(),
+		Name:       req.Name,
+		MountPoint: "/lambda/nfs/" + req.Name,
+		Created:    s.opts.Now().UTC(),
+		Region:     region,
+	}
+	s.fileSystems = append(s.fileSystems, fileSystem)
+
+	writeData(w, fileSystem)
+}
+
+func (s *Server) deleteFileSystem(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	for i, fileSystem := range s.fileSystems {
+		if fileSystem.Id != id {
+			continue
+		}
+		if s.fileSystemInUse(fileSystem.Name, s.opts.Now()) {
+			writeError(w, http.StatusBadRequest, ErrorCodeFileSystemInUse, "File system "+fileSystem.Name+" is in use",
+				"Terminate the instances using the file system, then try again.")
+			return
+		}
+		s.fileSystems = append(s.fileSystems[:i], s.fileSystems[i+1:]...)
+		writeData(w, map[string][]string{"deleted_ids": {id}})
+		return
+	}
+
+	writeError(w, http.StatusNotFound, ErrorCodeNotFound, "File system "+id+" not found", "")
+}
@@ -0,0 +1,52 @@
+package fakelambda
+
+import "net/http"
+
+// FirewallRule is an inbound firewall rule as returned by the API.
+type FirewallRule struct {
+	Protocol      string `json:"protocol"`
+	PortRange     []int  `json:"port_range,omitempty"`
+	SourceNetwork string `json:"source_network"`
+	Description   string `json:"description"`
+}
+
+var firewallProtocols = map[string]bool{"tcp": true, "udp": true, "icmp": true, "all": true}
+
+func defaultFirewallRules() []FirewallRule {
+	return []FirewallRule{
+		{Protocol: "tcp", PortRange: []int{22, 22}, SourceNetwork: "0.0.0.0/0", Description: "Allow SSH"},
+	}
+}
+
+func (s *Server) listFirewallRules(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeData(w, append([]FirewallRule{}, s.firewallRules...))
+}
+
+func (s *Server) replaceFirewallRules(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Data []FirewallRule `json:"data"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	for _, rule := range req.Data {
+		if !firewallProtocols[rule.Protocol] {
+			writeError(w, http.StatusBadRequest, ErrorCodeInvalidParameters, "Unsupported protocol "+rule.Protocol, "")
+			return
+		}
+		if len(rule.PortRange) != 0 && (len(rule.PortRange) != 2 || rule.PortRange[0] > rule.PortRange[1]) {
+			writeError(w, http.StatusBadRequest, ErrorCodeInvalidParameters, "port_range must be [from, to]", "")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.firewallRules = append([]FirewallRule{}, req.Data...)
+	writeData(w, s.firewallRules)
+}
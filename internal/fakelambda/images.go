@@ -0,0 +1,48 @@
+package fakelambda
+
+import (
+	"net/http"
+	"time"
+)
+
+// Image is a machine image as returned by the API.
+type Image struct {
+	Id           string    `json:"id"`
+	CreatedTime  time.Time `json:"created_time"`
+	UpdatedTime  time.Time `json:"updated_time"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	Family       string    `json:"family"`
+	Version      string    `json:"version"`
+	Architecture string    `json:"architecture"`
+	Region       Region    `json:"region"`
+}
+
+func defaultImages() []Image {
+	released := time.Date(2025, time.January, 15, 0, 0, 0, 0, time.UTC)
+
+	var images []Image
+	for _, regionName := range []string{"us-east-1", "us-west-1", "test-east-1"} {
+		region, _ := regionFor(regionName)
+		images = append(images,
+			Image{
+				Id: newId(), CreatedTime: released, UpdatedTime: released,
+				Name: "lambda-stack-24-04", Description: "Lambda Stack on Ubuntu 24.04",
+				Family: "lambda-stack-24-04", Version: "24.04.1", Architecture: "x86_64", Region: region,
+			},
+			Image{
+				Id: newId(), CreatedTime: released, UpdatedTime: released,
+				Name: "ubuntu-22-04", Description: "Ubuntu 22.04",
+				Family: "ubuntu-22-04", Version: "22.04.5", Architecture: "x86_64", Region: region,
+			},
+		)
+	}
+	return images
+}
+
+func (s *Server) listImages(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeData(w, append([]Image{}, s.images...))
+}
@@ -0,0 +1,438 @@
+package fakelambda
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Instance statuses reported by the API.
+const (
+	StatusBooting     = "booting"
+	StatusActive      = "active"
+	StatusUnhealthy   = "unhealthy"
+	StatusTerminating = "terminating"
+)
+
+// maxUserDataBytes is the largest user_data the launch endpoint accepts.
+const maxUserDataBytes = 1 << 20
+
+// Instance is an instance as returned by the API.
+type Instance struct {
+	Id              string       `json:"id"`
+	Name            string       `json:"name,omitempty"`
+	Ip              string       `json:"ip,omitempty"`
+	PrivateIp       string       `json:"private_ip,omitempty"`
+	Hostname        string       `json:"hostname,omitempty"`
+	Status          string       `json:"status"`
+	SshKeyNames     []string     `json:"ssh_key_names"`
+	FileSystemNames []string     `json:"file_system_names"`
+	Region          Region       `json:"region"`
+	InstanceType    InstanceType `json:"instance_type"`
+	IsReserved      bool         `json:"is_reserved"`
+}
+
+// instance is the server's record of an instance. Its status is derived from
+// the timestamps, so transitions happen without a background goroutine.
+type instance struct {
+	Instance
+	UserData string
+
+	bootedAt     time.Time
+	terminatedAt time.Time
+	terminating  bool
+
+	// statusOverride is set by SetInstanceStatus to simulate failures.
+	statusOverride string
+}
+
+// view returns the instance as the API reports it at now, and false if it has
+// finished terminating.
+func (s *Server) view(inst *instance, now time.Time) (Instance, bool) {
+	out := inst.Instance
+	out.SshKeyNames = append([]string{}, inst.SshKeyNames...)
+	out.FileSystemNames = append([]string{}, inst.FileSystemNames...)
+
+	switch {
+	case inst.terminating:
+		if !now.Before(inst.terminatedAt.Add(s.opts.TerminateDuration)) {
+			return Instance{}, false
+		}
+		out.Status = StatusTerminating
+	case inst.statusOverride != "":
+		out.Status = inst.statusOverride
+	case now.Before(inst.bootedAt):
+		out.Status = StatusBooting
+	default:
+		out.Status = StatusActive
+	}
+
+	// Addresses are only assigned once an instance has booted
+	if out.Status == StatusBooting {
+		out.Ip, out.PrivateIp, out.Hostname = "", "", ""
+	}
+
+	return out, true
+}
+
+// lookup returns the instance with the given ID at now, dropping it if it has
+// finished terminating. The caller must hold s.mu.
+func (s *Server) lookup(id string, now time.Time) (*instance, Instance, bool) {
+	inst, ok := s.instances[id]
+	if !ok {
+		return nil, Instance{}, false
+	}
+
+	view, ok := s.view(inst, now)
+	if !ok {
+		s.release(inst)
+		delete(s.instances, id)
+		return nil, Instance{}, false
+	}
+
+	return inst, view, true
+}
+
+// release returns a terminated instance's capacity. The caller must hold s.mu.
+func (s *Server) release(inst *instance) {
+	if regions, ok := s.capacity[inst.InstanceType.Name]; ok {
+		regions[inst.Region.Name]++
+	}
+}
+
+// Instances returns every instance that has not finished terminating,
+// ordered by ID.
+func (s *Server) Instances() []Instance {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.listLocked(s.opts.Now())
+}
+
+func (s *Server) listLocked(now time.Time) []Instance {
+	ids := make([]string, 0, len(s.instances))
+	for id := range s.instances {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	instances := []Instance{}
+	for _, id := range ids {
+		if _, view, ok := s.lookup(id, now); ok {
+			instances = append(instances, view)
+		}
+	}
+	return instances
+}
+
+// SetInstanceStatus forces the status an instance reports, for example
+// "unhealthy". An empty status restores the normal transitions.
+func (s *Server) SetInstanceStatus(id, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inst, _, ok := s.lookup(id, s.opts.Now())
+	if !ok {
+		return fmt.Errorf("instance %s not found", id)
+	}
+	inst.statusOverride = status
+	return nil
+}
+
+// RenameInstance changes an instance's name, as if it was edited in the
+// dashboard.
+func (s *Server) RenameInstance(id, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inst, _, ok := s.lookup(id, s.opts.Now())
+	if !ok {
+		return fmt.Errorf("instance %s not found", id)
+	}
+	inst.Name = name
+	return nil
+}
+
+// DeleteInstance removes an instance immediately, as if it was terminated
+// outside of Terraform and has since disappeared.
+func (s *Server) DeleteInstance(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	inst, _, ok := s.lookup(id, s.opts.Now())
+	if !ok {
+		return fmt.Errorf("instance %s not found", id)
+	}
+	s.release(inst)
+	delete(s.instances, id)
+	return nil
+}
+
+func (s *Server) listInstances(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	writeData(w, s.listLocked(s.opts.Now()))
+}
+
+func (s *Server) getInstance(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, view, ok := s.lookup(r.PathValue("id"), s.opts.Now())
+	if !ok {
+		writeError(w, http.StatusNotFound, ErrorCodeNotFound, "Instance "+r.PathValue("id")+" not found", "")
+		return
+	}
+
+	writeData(w, view)
+}
+
+func (s *Server) updateInstance(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name *string `json:"name"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.opts.Now()
+	inst, _, ok := s.lookup(r.PathValue("id"), now)
+	if !ok {
+		writeError(w, http.StatusNotFound, ErrorCodeNotFound, "Instance "+r.PathValue("id")+" not found", "")
+		return
+	}
+
+	if req.Name != nil {
+		if len(*req.Name) > 64 {
+			writeError(w, http.StatusBadRequest, ErrorCodeInvalidParameters, "name must be at most 64 characters", "")
+			return
+		}
+		inst.Name = *req.Name
+	}
+
+	view, _ := s.view(inst, now)
+	writeData(w, view)
+}
+
+type launchRequest struct {
+	RegionName       string   `json:"region_name"`
+	InstanceTypeName string   `json:"instance_type_name"`
+	SshKeyNames      []string `json:"ssh_key_names"`
+	FileSystemNames  []string `json:"file_system_names"`
+	Name             *string  `json:"name"`
+	UserData         string   `json:"user_data"`
+	Quantity         int      `json:"quantity"`
+}
+
+// SetLaunchLimit makes each launch call launch at most limit instances,
+// returning fewer IDs than requested when asked for more, as the API may. Zero
+// removes the limit.
+func (s *Server) SetLaunchLimit(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.launchLimit = limit
+}
+
+func (s *Server) launchInstances(w http.ResponseWriter, r *http.Request) {
+	var req launchRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if msg := s.validateLaunch(req); msg != "" {
+		writeError(w, http.StatusBadRequest, ErrorCodeInvalidParameters, msg, "")
+		return
+	}
+
+	if s.launchLimit > 0 && req.Quantity > s.launchLimit {
+		req.Quantity = s.launchLimit
+	}
+
+	if s.capacity[req.InstanceTypeName][req.RegionName] < req.Quantity {
+		writeError(w, http.StatusBadRequest, ErrorCodeInsufficientCapacity, "Not enough capacity to fulfill launch request.",
+			"Choose an instance type with more availability, or try your request again later.")
+		return
+	}
+	s.capacity[req.InstanceTypeName][req.RegionName] -= req.Quantity
+
+	region, _ := regionFor(req.RegionName)
+	now := s.opts.Now()
+
+	ids := make([]string, 0, req.Quantity)
+	for i := 0; i < req.Quantity; i++ {
+		id := newId()
+		s.nextIp++
+
+		inst := &instance{
+			Instance: Instance{
+				Id:              id,
+				Ip:              fmt.Sprintf("192.0.2.%d", s.nextIp%250+1),
+				PrivateIp:       fmt.Sprintf("10.0.%d.%d", s.nextIp/250%250, s.nextIp%250+1),
+				Hostname:        fmt.Sprintf("%s.fake.lambda.local", id[:12]),
+				SshKeyNames:     append([]string{}, req.SshKeyNames...),
+				FileSystemNames: append([]string{}, req.FileSystemNames...),
+				Region:          region,
+				InstanceType:    s.instanceTypes[req.InstanceTypeName],
+			},
+			UserData: req.UserData,
+			bootedAt: now.Add(s.opts.BootDuration),
+		}
+		if req.Name != nil {
+			inst.Name = *req.Name
+		}
+
+		s.instances[id] = inst
+		ids = append(ids, id)
+	}
+
+	writeData(w, map[string][]string{"instance_ids": ids})
+}
+
+// validateLaunch returns why req is invalid, or "" if it is valid. The caller
+// must hold s.mu.
+func (s *Server) validateLaunch(req launchRequest) string {
+	if _, ok := regionFor(req.RegionName); !ok {
+		return fmt.Sprintf("Unknown region %q", req.RegionName)
+	}
+	if _, ok := s.instanceTypes[req.InstanceTypeName]; !ok {
+		return fmt.Sprintf("Unknown instance type %q", req.InstanceTypeName)
+	}
+	if req.Quantity < 1 {
+		return "quantity must be at least 1"
+	}
+	if len(req.SshKeyNames) == 0 {
+		return "ssh_key_names must contain at least one SSH key"
+	}
+	for _, name := range req.SshKeyNames {
+		if s.findSshKey(name) == nil {
+			return fmt.Sprintf("SSH key %q does not exist", name)
+		}
+	}
+	for _, name := range req.FileSystemNames {
+		fileSystem := s.findFileSystem(name)
+		if fileSystem == nil {
+			return fmt.Sprintf("File system %q does not exist", name)
+		}
+		if fileSystem.Region.Name != req.RegionName {
+			return fmt.Sprintf("File system %q is in region %s, not %s", name, fileSystem.Region.Name, req.RegionName)
+		}
+	}
+	if req.Name != nil && len(*req.Name) > 64 {
+		return "name must be at most 64 characters"
+	}
+	if len(req.UserData) > maxUserDataBytes {
+		return fmt.Sprintf("user_data must be at most %d bytes", maxUserDataBytes)
+	}
+	return ""
+}
+
+type instanceIdsRequest struct {
+	InstanceIds []string `json:"instance_ids"`
+}
+
+func (s *Server) terminateInstances(w http.ResponseWriter, r *http.Request) {
+	var req instanceIdsRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.opts.Now()
+	targets, ok := s.lookupAll(w, req.InstanceIds, now)
+	if !ok {
+		return
+	}
+
+	terminated := []Instance{}
+	for _, inst := range targets {
+		if !inst.terminating {
+			inst.terminating = true
+			inst.terminatedAt = now
+		}
+		if view, ok := s.view(inst, now); ok {
+			terminated = append(terminated, view)
+		} else {
+			s.release(inst)
+			delete(s.instances, inst.Id)
+		}
+	}
+
+	writeData(w, map[string][]Instance{"terminated_instances": terminated})
+}
+
+func (s *Server) restartInstances(w http.ResponseWriter, r *http.Request) {
+	var req instanceIdsRequest
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.opts.Now()
+	targets, ok := s.lookupAll(w, req.InstanceIds, now)
+	if !ok {
+		return
+	}
+
+	restarted := []Instance{}
+	for _, inst := range targets {
+		if inst.terminating {
+			writeError(w, http.StatusBadRequest, ErrorCodeInvalidParameters, "Instance "+inst.Id+" is terminating", "")
+			return
+		}
+	}
+	for _, inst := range targets {
+		inst.bootedAt = now.Add(s.opts.RebootDuration)
+		inst.statusOverride = ""
+		view, _ := s.view(inst, now)
+		restarted = append(restarted, view)
+	}
+
+	writeData(w, map[string][]Instance{"restarted_instances": restarted})
+}
+
+// lookupAll returns the instances with the given IDs, writing an error
+// response and returning false if any is missing. The caller must hold s.mu.
+func (s *Server) lookupAll(w http.ResponseWriter, ids []string, now time.Time) ([]*instance, bool) {
+	if len(ids) == 0 {
+		writeError(w, http.StatusBadRequest, ErrorCodeInvalidParameters, "instance_ids must not be empty", "")
+		return nil, false
+	}
+
+	targets := make([]*instance, 0, len(ids))
+	for _, id := range ids {
+		inst, _, ok := s.lookup(id, now)
+		if !ok {
+			writeError(w, http.StatusNotFound, ErrorCodeNotFound, "Instance "+id+" not found", "")
+			return nil, false
+		}
+		targets = append(targets, inst)
+	}
+	return targets, true
+}
+
+// newId returns a random 32 character hex ID like the ones the API uses.
+func newId() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
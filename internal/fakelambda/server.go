@@ -0,0 +1,231 @@
+// Package fakelambda implements a stateful, in-memory stand-in for the Lambda
+// Cloud API. It serves the endpoints the provider uses, moves instances
+// through their status transitions, and can inject faults such as capacity
+// errors, rate limiting, server errors and latency.
+//
+// In tests, serve it with httptest:
+//
+//	fake := fakelambda.New(fakelambda.Options{})
+//	server := httptest.NewServer(fake)
+//	defer server.Close()
+//
+// cmd/fake-lambda-api runs it as a standalone server.
+package fakelambda
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Error codes returned in the API error envelope.
+const (
+	ErrorCodeInvalidAPIKey        = "global/invalid-api-key"
+	ErrorCodeNotFound             = "global/object-does-not-exist"
+	ErrorCodeInvalidParameters    = "global/invalid-parameters"
+	ErrorCodeTooManyRequests      = "global/too-many-requests"
+	ErrorCodeInternal             = "global/internal-error"
+	ErrorCodeInsufficientCapacity = "instance-operations/launch/insufficient-capacity"
+	ErrorCodeFileSystemInUse      = "filesystems/filesystem-in-use"
+	ErrorCodeDuplicate            = "global/duplicate"
+)
+
+// Options configures a Server. The zero value accepts any API key and moves
+// instances through their transitions instantly.
+type Options struct {
+	// APIKey is the bearer token requests must carry. Any non-empty token
+	// is accepted when it is empty.
+	APIKey string
+
+	// BootDuration is how long a launched instance stays "booting", and
+	// RebootDuration how long a restarted one does.
+	BootDuration   time.Duration
+	RebootDuration time.Duration
+
+	// TerminateDuration is how long a terminated instance stays
+	// "terminating" before it disappears.
+	TerminateDuration time.Duration
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// Request is a request the server received, recorded for assertions.
+type Request struct {
+	Method     string
+	Path       string
+	StatusCode int
+}
+
+// Server is the fake API. It implements http.Handler and is safe for
+// concurrent use.
+type Server struct {
+	opts Options
+	mux  *http.ServeMux
+
+	mu            sync.Mutex
+	instanceTypes map[string]InstanceType
+	// capacity holds the number of instances that can still be launched,
+	// keyed by instance type and then region.
+	capacity      map[string]map[string]int
+	instances     map[string]*instance
+	sshKeys       []SshKey
+	fileSystems   []FileSystem
+	images        []Image
+	firewallRules []FirewallRule
+	faults        []*Fault
+	requests      []Request
+	nextIp        int
+	// launchLimit caps the instances launched per call; zero is no cap.
+	launchLimit int
+}
+
+// New returns a server with the default catalog, images and firewall rules,
+// and no instances, SSH keys or file systems.
+func New(opts Options) *Server {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	s := &Server{
+		opts:          opts,
+		instanceTypes: make(map[string]InstanceType),
+		capacity:      make(map[string]map[string]int),
+		instances:     make(map[string]*instance),
+		images:        defaultImages(),
+		firewallRules: defaultFirewallRules(),
+	}
+
+	for _, entry := range defaultCatalog() {
+		s.instanceTypes[entry.instanceType.Name] = entry.instanceType
+		s.capacity[entry.instanceType.Name] = make(map[string]int)
+		for _, region := range entry.regions {
+			s.capacity[entry.instanceType.Name][region] = defaultCapacity
+		}
+	}
+
+	s.mux = http.NewServeMux()
+	s.routes()
+
+	return s
+}
+
+func (s *Server) routes() {
+	s.mux.HandleFunc("GET /api/v1/instance-types", s.listInstanceTypes)
+
+	s.mux.HandleFunc("GET /api/v1/instances", s.listInstances)
+	s.mux.HandleFunc("GET /api/v1/instances/{id}", s.getInstance)
+	s.mux.HandleFunc("POST /api/v1/instances/{id}", s.updateInstance)
+	s.mux.HandleFunc("POST /api/v1/instance-operations/launch", s.launchInstances)
+	s.mux.HandleFunc("POST /api/v1/instance-operations/terminate", s.terminateInstances)
+	s.mux.HandleFunc("POST /api/v1/instance-operations/restart", s.restartInstances)
+	s.mux.HandleFunc("POST /api/v1/instance-operations/cold-reboot", s.restartInstances)
+
+	s.mux.HandleFunc("GET /api/v1/ssh-keys", s.listSshKeys)
+	s.mux.HandleFunc("POST /api/v1/ssh-keys", s.createSshKey)
+	s.mux.HandleFunc("DELETE /api/v1/ssh-keys/{id}", s.deleteSshKey)
+
+	s.mux.HandleFunc("GET /api/v1/file-systems", s.listFileSystems)
+	s.mux.HandleFunc("POST /api/v1/filesystems", s.createFileSystem)
+	s.mux.HandleFunc("DELETE /api/v1/filesystems/{id}", s.deleteFileSystem)
+
+	s.mux.HandleFunc("GET /api/v1/images", s.listImages)
+
+	s.mux.HandleFunc("GET /api/v1/firewall-rules", s.listFirewallRules)
+	s.mux.HandleFunc("PUT /api/v1/firewall-rules", s.replaceFirewallRules)
+
+	s.mux.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusNotFound, ErrorCodeNotFound, "Unknown endpoint "+r.Method+" "+r.URL.Path, "")
+	})
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// The admin endpoints let a standalone server be steered like the
+	// in-process one, so they bypass faults and authentication.
+	if strings.HasPrefix(r.URL.Path, adminPathPrefix) {
+		s.serveAdmin(w, r)
+		return
+	}
+
+	w.Header().Set("X-Request-Id", newId())
+
+	recorder := &statusRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+	defer func() {
+		s.mu.Lock()
+		s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, StatusCode: recorder.statusCode})
+		s.mu.Unlock()
+	}()
+
+	if s.applyFault(recorder, r) {
+		return
+	}
+
+	if !s.authorized(r) {
+		writeError(recorder, http.StatusUnauthorized, ErrorCodeInvalidAPIKey, "API key was invalid, expired, or deleted.",
+			"Check your API key or create a new one, then try again.")
+		return
+	}
+
+	s.mux.ServeHTTP(recorder, r)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return false
+	}
+	return s.opts.APIKey == "" || token == s.opts.APIKey
+}
+
+// Requests returns the API requests received so far, oldest first.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// statusRecorder remembers the status code written through it.
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (r *statusRecorder) WriteHeader(statusCode int) {
+	r.statusCode = statusCode
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+// writeData writes v wrapped in the {"data": ...} envelope.
+func writeData(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": v})
+}
+
+// writeError writes the API error envelope.
+func writeError(w http.ResponseWriter, statusCode int, code, message, suggestion string) {
+	body := map[string]interface{}{
+		"error": map[string]string{
+			"code":       code,
+			"message":    message,
+			"suggestion": suggestion,
+		},
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// decodeBody decodes a JSON request body into v, writing an error response
+// and returning false if that fails.
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, ErrorCodeInvalidParameters, "Invalid request body: "+err.Error(), "")
+		return false
+	}
+	return true
+}
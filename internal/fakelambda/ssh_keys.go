@@ -0,0 +1,177 @@
+package fakelambda
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+)
+
+// SshKey is an SSH key as returned by the API. PrivateKey is only set in the
+// response to generating a key pair.
+type SshKey struct {
+	Id         string `json:"id"`
+	Name       string `json:"name"`
+	PublicKey  string `json:"public_key"`
+	PrivateKey string `json:"private_key,omitempty"`
+}
+
+// SshKeys returns the SSH keys of the account.
+func (s *Server) SshKeys() []SshKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]SshKey{}, s.sshKeys...)
+}
+
+// AddSshKey adds a generated SSH key to the account, as if it was created in
+// the dashboard, and returns it with its private key.
+func (s *Server) AddSshKey(name string) (SshKey, error) {
+	publicKey, privateKey, err := generateKeyPair()
+	if err != nil {
+		return SshKey{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findSshKey(name) != nil {
+		return SshKey{}, fmt.Errorf("SSH key %s already exists", name)
+	}
+
+	key := SshKey{Id: newId(), Name: name, PublicKey: publicKey}
+	s.sshKeys = append(s.sshKeys, key)
+
+	key.PrivateKey = privateKey
+	return key, nil
+}
+
+// findSshKey returns the SSH key with the given name. The caller must hold s.mu.
+func (s *Server) findSshKey(name string) *SshKey {
+	for i := range s.sshKeys {
+		if s.sshKeys[i].Name == name {
+			return &s.sshKeys[i]
+		}
+	}
+	return nil
+}
+
+func (s *Server) listSshKeys(w http.ResponseWriter, r *http.Request) {
+	writeData(w, s.SshKeys())
+}
+
+func (s *Server) createSshKey(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name      string  `json:"name"`
+		PublicKey *string `json:"public_key"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	if req.Name == "" || len(req.Name) > 64 {
+		writeError(w, http.StatusBadRequest, ErrorCodeInvalidParameters, "name must be between 1 and 64 characters", "")
+		return
+	}
+
+	key := SshKey{Id: newId(), Name: req.Name}
+
+	if req.PublicKey != nil {
+		if !validPublicKey(*req.PublicKey) {
+			writeError(w, http.StatusBadRequest, ErrorCodeInvalidParameters, "public_key is not a valid OpenSSH public key", "")
+			return
+		}
+		key.PublicKey = strings.TrimSpace(*req.PublicKey)
+	} else {
+		publicKey, privateKey, err := generateKeyPair()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, ErrorCodeInternal, "Unable to generate key pair: "+err.Error(), "")
+			return
+		}
+		key.PublicKey = publicKey
+		key.PrivateKey = privateKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findSshKey(req.Name) != nil {
+		writeError(w, http.StatusBadRequest, ErrorCodeDuplicate, "SSH key with name "+req.Name+" already exists", "")
+		return
+	}
+
+	stored := key
+	stored.PrivateKey = ""
+	s.sshKeys = append(s.sshKeys, stored)
+
+	writeData(w, key)
+}
+
+func (s *Server) deleteSshKey(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	for i, key := range s.sshKeys {
+		if key.Id == id {
+			s.sshKeys = append(s.sshKeys[:i], s.sshKeys[i+1:]...)
+			writeData(w, map[string]interface{}{})
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, ErrorCodeNotFound, "SSH key "+id+" not found", "")
+}
+
+// validPublicKey reports whether key looks like an OpenSSH public key, i.e. a
+// key type followed by base64 data.
+func validPublicKey(key string) bool {
+	fields := strings.Fields(key)
+	if len(fields) < 2 || !strings.HasPrefix(fields[0], "ssh-") && !strings.HasPrefix(fields[0], "ecdsa-") {
+		return false
+	}
+	_, err := base64.StdEncoding.DecodeString(fields[1])
+	return err == nil
+}
+
+// generateKeyPair returns a new RSA key pair as an OpenSSH public key and a
+// PEM encoded private key, the formats the API uses.
+func generateKeyPair() (string, string, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", "", err
+	}
+
+	var wire []byte
+	wire = appendSshString(wire, []byte("ssh-rsa"))
+	wire = appendSshString(wire, mpint(big.NewInt(int64(privateKey.E))))
+	wire = appendSshString(wire, mpint(privateKey.N))
+
+	publicKey := "ssh-rsa " + base64.StdEncoding.EncodeToString(wire)
+	privatePem := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	})
+
+	return publicKey, string(privatePem), nil
+}
+
+func appendSshString(b, s []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
+
+// mpint encodes a positive integer in the SSH wire format.
+func mpint(n *big.Int) []byte {
+	b := n.Bytes()
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...)
+	}
+	return b
+}
@@ -0,0 +1,229 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/action"
+	"github.com/hashicorp/terraform-plugin-framework/action/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ action.Action = &InstanceRebootAction{}
+var _ action.ActionWithConfigure = &InstanceRebootAction{}
+
+const (
+	// defaultRebootTimeout bounds how long wait_for_active waits by default.
+	defaultRebootTimeout = 20 * time.Minute
+
+	// defaultRebootPollInterval is how often the instance status is polled
+	// while waiting for it to become active again.
+	defaultRebootPollInterval = 10 * time.Second
+
+	// defaultRebootSettleTime is how long an instance may keep reporting
+	// "active" after the operation before it is assumed to have already come
+	// back.
+	defaultRebootSettleTime = time.Minute
+)
+
+// NewInstanceRestartAction returns the lambda_instance_restart action, which
+// restarts the operating system of an instance.
+func NewInstanceRestartAction() action.Action {
+	return &InstanceRebootAction{
+		typeSuffix:   "_instance_restart",
+		description:  "Restarts a Lambda Cloud instance.",
+		operation:    "restart",
+		pollInterval: defaultRebootPollInterval,
+		settleTime:   defaultRebootSettleTime,
+		invoke: func(ctx context.Context, client *ProviderConfig, instanceId string) error {
+			return client.RestartInstances(ctx, []string{instanceId})
+		},
+	}
+}
+
+// NewInstanceColdRebootAction returns the lambda_instance_cold_reboot action,
+// which power cycles an instance that no longer responds to a restart.
+func NewInstanceColdRebootAction() action.Action {
+	return &InstanceRebootAction{
+		typeSuffix:   "_instance_cold_reboot",
+		description:  "Cold reboots (power cycles) a Lambda Cloud instance. Use this when an instance does not respond to a restart.",
+		operation:    "cold reboot",
+		pollInterval: defaultRebootPollInterval,
+		settleTime:   defaultRebootSettleTime,
+		invoke: func(ctx context.Context, client *ProviderConfig, instanceId string) error {
+			return client.ColdRebootInstances(ctx, []string{instanceId})
+		},
+	}
+}
+
+// InstanceRebootAction defines the restart and cold reboot actions, which only
+// differ in the instance operation they call.
+type InstanceRebootAction struct {
+	client *ProviderConfig
+
+	typeSuffix  string
+	description string
+	operation   string
+	invoke      func(ctx context.Context, client *ProviderConfig, instanceId string) error
+
+	// pollInterval and settleTime tune waitForActive, and are shortened by
+	// tests.
+	pollInterval time.Duration
+	settleTime   time.Duration
+}
+
+// InstanceRebootActionModel describes the action data model.
+type InstanceRebootActionModel struct {
+	InstanceId    types.String `tfsdk:"instance_id"`
+	WaitForActive types.Bool   `tfsdk:"wait_for_active"`
+	Timeout       types.String `tfsdk:"timeout"`
+}
+
+func (a *InstanceRebootAction) Metadata(ctx context.Context, req action.MetadataRequest, resp *action.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + a.typeSuffix
+}
+
+func (a *InstanceRebootAction) Schema(ctx context.Context, req action.SchemaRequest, resp *action.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: a.description,
+		Attributes: map[string]schema.Attribute{
+			"instance_id": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "The ID of the instance",
+			},
+			"wait_for_active": schema.BoolAttribute{
+				Optional:            true,
+				MarkdownDescription: "Wait until the instance is active again before completing. Defaults to `false`",
+			},
+			"timeout": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: fmt.Sprintf("How long to wait for the instance to become active, as a duration. Defaults to `%s`", defaultRebootTimeout),
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+		},
+	}
+}
+
+func (a *InstanceRebootAction) Configure(ctx context.Context, req action.ConfigureRequest, resp *action.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*ProviderConfig)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Action Configure Type",
+			fmt.Sprintf("Expected *ProviderConfig, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	a.client = client
+}
+
+func (a *InstanceRebootAction) Invoke(ctx context.Context, req action.InvokeRequest, resp *action.InvokeResponse) {
+	var data InstanceRebootActionModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	instanceId := data.InstanceId.ValueString()
+
+	resp.SendProgress(action.InvokeProgressEvent{
+		Message: fmt.Sprintf("Requesting %s of instance %s", a.operation, instanceId),
+	})
+
+	if err := a.invoke(ctx, a.client, instanceId); err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to %s instance %s, got error: %s", a.operation, instanceId, err))
+		return
+	}
+
+	tflog.Trace(ctx, "invoked an instance reboot action", map[string]interface{}{
+		"instance_id": instanceId,
+		"operation":   a.operation,
+	})
+
+	if !data.WaitForActive.ValueBool() {
+		return
+	}
+
+	// Validated by durationValidator
+	timeout := defaultRebootTimeout
+	if !data.Timeout.IsNull() {
+		timeout, _ = time.ParseDuration(data.Timeout.ValueString())
+	}
+
+	if err := a.waitForActive(ctx, instanceId, timeout, resp.SendProgress); err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Instance %s did not become active after %s, got error: %s", instanceId, a.operation, err))
+		return
+	}
+
+	resp.SendProgress(action.InvokeProgressEvent{
+		Message: fmt.Sprintf("Instance %s is active", instanceId),
+	})
+}
+
+// waitForActive polls the instance until it reports "active" again. The API
+// can keep reporting "active" for a short while after the operation is
+// accepted, so an active status only counts once the instance has been seen
+// in another state, or once settleTime has passed.
+func (a *InstanceRebootAction) waitForActive(ctx context.Context, instanceId string, timeout time.Duration, sendProgress func(action.InvokeProgressEvent)) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	ticker := time.NewTicker(a.pollInterval)
+	defer ticker.Stop()
+
+	lastStatus := ""
+	leftActive := false
+
+	stopped := func() error {
+		return fmt.Errorf("stopped waiting after %s (last status %q): %w", time.Since(start).Round(time.Second), lastStatus, ctx.Err())
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return stopped()
+		case <-ticker.C:
+		}
+
+		instance, err := a.client.GetInstance(ctx, instanceId)
+		if err != nil {
+			// The timeout may have cut the request short
+			if ctx.Err() != nil {
+				return stopped()
+			}
+			return err
+		}
+
+		if instance.Status != lastStatus {
+			sendProgress(action.InvokeProgressEvent{
+				Message: fmt.Sprintf("Instance %s is %s (%s elapsed)", instanceId, instance.Status, time.Since(start).Round(time.Second)),
+			})
+			lastStatus = instance.Status
+		}
+
+		switch instance.Status {
+		case "active":
+			if leftActive || time.Since(start) >= a.settleTime {
+				return nil
+			}
+		case "terminating", "terminated", "preempted":
+			return fmt.Errorf("instance is %s", instance.Status)
+		default:
+			leftActive = true
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/action"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+
+	"github.com/albertocavalcante/terraform-provider-lambda/internal/fakelambda"
+)
+
+// newRebootActionTest returns an action polling every 10ms and settling after
+// settleTime, configured against an API stand-in that reboots instances in
+// rebootDuration, and the ID of an active instance.
+func newRebootActionTest(t *testing.T, newAction func() action.Action, rebootDuration, settleTime time.Duration) (*InstanceRebootAction, *fakelambda.Server, string) {
+	t.Helper()
+
+	fake := fakelambda.New(fakelambda.Options{RebootDuration: rebootDuration})
+	if _, err := fake.AddSshKey("reboot"); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client := &ProviderConfig{ApiKey: "test", Endpoint: server.URL, HTTPClient: &http.Client{}}
+	instanceIds, err := client.LaunchInstances(context.Background(), LaunchRequest{
+		RegionName:       "us-east-1",
+		InstanceTypeName: "gpu_1x_a10",
+		SshKeyNames:      []string{"reboot"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := newAction().(*InstanceRebootAction)
+	a.client = client
+	a.pollInterval = 10 * time.Millisecond
+	a.settleTime = settleTime
+
+	return a, fake, instanceIds[0]
+}
+
+// invokeRebootAction invokes the action as Terraform would and returns the
+// response and the progress messages it sent.
+func invokeRebootAction(t *testing.T, a *InstanceRebootAction, instanceId string, waitForActive bool, timeout string) (*action.InvokeResponse, []string) {
+	t.Helper()
+	ctx := context.Background()
+
+	var schemaResp action.SchemaResponse
+	a.Schema(ctx, action.SchemaRequest{}, &schemaResp)
+
+	timeoutValue := tftypes.NewValue(tftypes.String, nil)
+	if timeout != "" {
+		timeoutValue = tftypes.NewValue(tftypes.String, timeout)
+	}
+
+	config := tfsdk.Config{
+		Schema: schemaResp.Schema,
+		Raw: tftypes.NewValue(schemaResp.Schema.Type().TerraformType(ctx), map[string]tftypes.Value{
+			"instance_id":     tftypes.NewValue(tftypes.String, instanceId),
+			"wait_for_active": tftypes.NewValue(tftypes.Bool, waitForActive),
+			"timeout":         timeoutValue,
+		}),
+	}
+
+	var progress []string
+	resp := &action.InvokeResponse{
+		SendProgress: func(event action.InvokeProgressEvent) { progress = append(progress, event.Message) },
+	}
+	a.Invoke(ctx, action.InvokeRequest{Config: config}, resp)
+
+	return resp, progress
+}
+
+// countOperationRequests counts the calls made to an instance operation.
+func countOperationRequests(fake *fakelambda.Server, operation string) int {
+	var calls int
+	for _, req := range fake.Requests() {
+		if req.Path == "/api/v1/instance-operations/"+operation {
+			calls++
+		}
+	}
+	return calls
+}
+
+func TestInstanceRebootAction(t *testing.T) {
+	t.Run("restart without waiting", func(t *testing.T) {
+		a, fake, instanceId := newRebootActionTest(t, NewInstanceRestartAction, time.Hour, time.Minute)
+
+		resp, _ := invokeRebootAction(t, a, instanceId, false, "")
+		if resp.Diagnostics.HasError() {
+			t.Fatal(resp.Diagnostics)
+		}
+
+		if got := countOperationRequests(fake, "restart"); got != 1 {
+			t.Errorf("expected one restart call, got %d", got)
+		}
+		if got := countOperationRequests(fake, "cold-reboot"); got != 0 {
+			t.Errorf("expected no cold reboot calls, got %d", got)
+		}
+	})
+
+	t.Run("cold reboot waits for the instance to come back", func(t *testing.T) {
+		a, fake, instanceId := newRebootActionTest(t, NewInstanceColdRebootAction, 200*time.Millisecond, time.Minute)
+
+		start := time.Now()
+		resp, progress := invokeRebootAction(t, a, instanceId, true, "")
+		if resp.Diagnostics.HasError() {
+			t.Fatal(resp.Diagnostics)
+		}
+
+		if got := countOperationRequests(fake, "cold-reboot"); got != 1 {
+			t.Errorf("expected one cold reboot call, got %d", got)
+		}
+		if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+			t.Errorf("expected to wait out the reboot, returned after %s", elapsed)
+		}
+
+		messages := strings.Join(progress, "\n")
+		if !strings.Contains(messages, "is booting") || !strings.HasSuffix(messages, "Instance "+instanceId+" is active") {
+			t.Errorf("expected progress through booting to active, got:\n%s", messages)
+		}
+	})
+
+	t.Run("an instance that stays active is done after the settle time", func(t *testing.T) {
+		a, _, instanceId := newRebootActionTest(t, NewInstanceRestartAction, 0, 100*time.Millisecond)
+
+		start := time.Now()
+		resp, _ := invokeRebootAction(t, a, instanceId, true, "")
+		if resp.Diagnostics.HasError() {
+			t.Fatal(resp.Diagnostics)
+		}
+		if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+			t.Errorf("expected to wait for the settle time, returned after %s", elapsed)
+		}
+	})
+
+	t.Run("wait_for_active times out", func(t *testing.T) {
+		a, _, instanceId := newRebootActionTest(t, NewInstanceRestartAction, time.Hour, time.Minute)
+
+		resp, _ := invokeRebootAction(t, a, instanceId, true, "200ms")
+		if !resp.Diagnostics.HasError() {
+			t.Fatal("expected the wait to time out")
+		}
+		if detail := resp.Diagnostics.Errors()[0].Detail(); !strings.Contains(detail, "did not become active after restart") ||
+			!strings.Contains(detail, `last status "booting"`) {
+			t.Errorf("unexpected error %q", detail)
+		}
+	})
+
+	t.Run("a terminated instance stops the wait", func(t *testing.T) {
+		a, fake, instanceId := newRebootActionTest(t, NewInstanceRestartAction, time.Hour, time.Minute)
+
+		// The instance is terminated while it reboots
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			if err := fake.SetInstanceStatus(instanceId, fakelambda.StatusTerminating); err != nil {
+				t.Error(err)
+			}
+		}()
+
+		resp, _ := invokeRebootAction(t, a, instanceId, true, "10s")
+		if !resp.Diagnostics.HasError() {
+			t.Fatal("expected the wait to fail")
+		}
+		if detail := resp.Diagnostics.Errors()[0].Detail(); !strings.Contains(detail, "instance is terminating") {
+			t.Errorf("unexpected error %q", detail)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Error codes returned by the Lambda Cloud API that the provider reacts to.
+const (
+	errorCodeInsufficientCapacity = "instance-operations/launch/insufficient-capacity"
+	errorCodeInvalidAPIKey        = "global/invalid-api-key"
+	errorCodeRevokedAPIKey        = "global/revoked-api-key"
+	errorCodeAccountInactive      = "global/account-inactive"
+)
+
+// APIError represents a non-success response from the Lambda Cloud API
+type APIError struct {
+	// Operation is a short description of the call, e.g. "launch".
+	Operation  string
+	StatusCode int
+	Code       string
+	Message    string
+	Suggestion string
+}
+
+// apiErrorResponse represents the error envelope returned by the API
+type apiErrorResponse struct {
+	Error struct {
+		Code       string `json:"code"`
+		Message    string `json:"message"`
+		Suggestion string `json:"suggestion"`
+	} `json:"error"`
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s API returned status %d", e.Operation, e.StatusCode)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Code != "" {
+		msg += fmt.Sprintf(" (%s)", e.Code)
+	}
+	if e.Suggestion != "" {
+		msg += ". " + e.Suggestion
+	}
+	return msg
+}
+
+// newAPIError builds an APIError from an unsuccessful response. The body is
+// parsed on a best-effort basis; a body that is not the documented error
+// envelope still yields an error carrying the status code.
+func newAPIError(operation string, httpResp *http.Response) error {
+	apiErr := &APIError{
+		Operation:  operation,
+		StatusCode: httpResp.StatusCode,
+	}
+
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return apiErr
+	}
+
+	var errResp apiErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil {
+		apiErr.Code = errResp.Error.Code
+		apiErr.Message = errResp.Error.Message
+		apiErr.Suggestion = errResp.Error.Suggestion
+	}
+
+	return apiErr
+}
+
+// isInsufficientCapacity reports whether err is the API telling us the
+// requested instance type has no capacity in the requested region.
+func isInsufficientCapacity(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == errorCodeInsufficientCapacity
+}
+
+// isNotFound reports whether err is the API telling us the object does not
+// exist, for example an instance that was terminated outside Terraform.
+func isNotFound(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusNotFound
+}
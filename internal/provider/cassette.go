@@ -0,0 +1,321 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// Environment variables that turn on recording or replaying of API traffic.
+// Each names a cassette file.
+const (
+	envHTTPRecord = "LAMBDA_HTTP_RECORD"
+	envHTTPReplay = "LAMBDA_HTTP_REPLAY"
+)
+
+// redacted replaces secrets in recorded traffic.
+const redacted = "REDACTED"
+
+// cassetteSecretKeys are JSON object keys whose values are never recorded.
+var cassetteSecretKeys = map[string]bool{
+	"private_key": true,
+}
+
+// cassette is the file format of recorded API traffic.
+type cassette struct {
+	Interactions []cassetteInteraction `json:"interactions"`
+}
+
+type cassetteInteraction struct {
+	Request  cassetteRequest  `json:"request"`
+	Response cassetteResponse `json:"response"`
+}
+
+// cassetteRequest is a recorded request. The endpoint is left out so that a
+// cassette can be replayed against any endpoint, and no headers are kept so
+// that the API key never reaches the file.
+type cassetteRequest struct {
+	Method string        `json:"method"`
+	Path   string        `json:"path"`
+	Body   *cassetteBody `json:"body,omitempty"`
+}
+
+type cassetteResponse struct {
+	StatusCode int                 `json:"status_code"`
+	Headers    map[string][]string `json:"headers,omitempty"`
+	Body       *cassetteBody       `json:"body,omitempty"`
+}
+
+// cassetteBody holds a JSON body as JSON, so that cassettes are readable, and
+// anything else as text.
+type cassetteBody struct {
+	JSON json.RawMessage `json:"json,omitempty"`
+	Text string          `json:"text,omitempty"`
+}
+
+// newCassetteBody scrubs and wraps a body, returning nil for an empty one.
+func newCassetteBody(body []byte) *cassetteBody {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return &cassetteBody{Text: string(body)}
+	}
+
+	// Marshalling sorts object keys, which also makes bodies comparable
+	scrubbed, err := json.Marshal(scrubJSON(value, cassetteSecretKeys, redacted))
+	if err != nil {
+		return &cassetteBody{Text: string(body)}
+	}
+	return &cassetteBody{JSON: scrubbed}
+}
+
+func (b *cassetteBody) bytes() []byte {
+	if b == nil {
+		return nil
+	}
+	if b.JSON != nil {
+		return b.JSON
+	}
+	return []byte(b.Text)
+}
+
+// equal reports whether two bodies hold the same content. JSON is compared
+// compacted, since cassette files are indented.
+func (b *cassetteBody) equal(other *cassetteBody) bool {
+	if b == nil || other == nil {
+		return b == other
+	}
+	if b.JSON == nil || other.JSON == nil {
+		return b.JSON == nil && other.JSON == nil && b.Text == other.Text
+	}
+
+	var x, y bytes.Buffer
+	if json.Compact(&x, b.JSON) != nil || json.Compact(&y, other.JSON) != nil {
+		return false
+	}
+	return bytes.Equal(x.Bytes(), y.Bytes())
+}
+
+// scrubJSON replaces the values of the given keys anywhere in a decoded JSON
+// value.
+func scrubJSON(value interface{}, keys map[string]bool, replacement string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if keys[key] {
+				v[key] = replacement
+				continue
+			}
+			v[key] = scrubJSON(item, keys, replacement)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = scrubJSON(item, keys, replacement)
+		}
+	}
+	return value
+}
+
+// cassetteTransportFromEnv wraps next in a recording or replaying transport
+// when LAMBDA_HTTP_RECORD or LAMBDA_HTTP_REPLAY is set, and returns next
+// unchanged otherwise. replaying reports whether responses come from a
+// cassette rather than the API.
+func cassetteTransportFromEnv(next http.RoundTripper) (transport http.RoundTripper, replaying bool, err error) {
+	recordPath, replayPath := os.Getenv(envHTTPRecord), os.Getenv(envHTTPReplay)
+
+	switch {
+	case recordPath != "" && replayPath != "":
+		return nil, false, fmt.Errorf("only one of %s and %s may be set", envHTTPRecord, envHTTPReplay)
+	case recordPath != "":
+		recorder, err := newCassetteRecorder(recordPath, next)
+		return recorder, false, err
+	case replayPath != "":
+		replayer, err := newCassetteReplayer(replayPath)
+		return replayer, true, err
+	}
+
+	return next, false, nil
+}
+
+// cassetteRecorder passes requests through to the API and appends every
+// interaction to a cassette file. Terraform starts a new provider process for
+// each command, so an existing cassette is extended rather than replaced.
+type cassetteRecorder struct {
+	path string
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	cassette cassette
+}
+
+func newCassetteRecorder(path string, next http.RoundTripper) (*cassetteRecorder, error) {
+	recorder := &cassetteRecorder{path: path, next: next}
+
+	existing, err := readCassette(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		recorder.cassette = *existing
+	}
+
+	return recorder, nil
+}
+
+func (r *cassetteRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readAndRestoreBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := readAndRestoreBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	interaction := cassetteInteraction{
+		Request: cassetteRequest{
+			Method: req.Method,
+			Path:   req.URL.RequestURI(),
+			Body:   newCassetteBody(reqBody),
+		},
+		Response: cassetteResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    resp.Header.Clone(),
+			Body:       newCassetteBody(respBody),
+		},
+	}
+	delete(interaction.Response.Headers, "Set-Cookie")
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	if err := writeCassette(r.path, &r.cassette); err != nil {
+		return nil, fmt.Errorf("unable to write cassette %s: %w", r.path, err)
+	}
+
+	return resp, nil
+}
+
+// cassetteReplayer answers requests from a cassette without calling the API.
+// A request is answered by the first unused interaction with the same method,
+// path and body. Once those are used up, the last one is repeated, which
+// covers the extra polling a replayed run may do.
+type cassetteReplayer struct {
+	path string
+
+	mu       sync.Mutex
+	cassette cassette
+	used     []bool
+}
+
+func newCassetteReplayer(path string) (*cassetteReplayer, error) {
+	c, err := readCassette(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cassetteReplayer{path: path, cassette: *c, used: make([]bool, len(c.Interactions))}, nil
+}
+
+func (r *cassetteReplayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readAndRestoreBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	body := newCassetteBody(reqBody)
+	path := req.URL.RequestURI()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	match := -1
+	for i, interaction := range r.cassette.Interactions {
+		if interaction.Request.Method != req.Method || interaction.Request.Path != path ||
+			!interaction.Request.Body.equal(body) {
+			continue
+		}
+		match = i
+		if !r.used[i] {
+			break
+		}
+	}
+
+	if match < 0 {
+		return nil, fmt.Errorf("cassette %s has no recorded response for %s %s", r.path, req.Method, path)
+	}
+	r.used[match] = true
+
+	recorded := r.cassette.Interactions[match].Response
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode:    recorded.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header(recorded.Headers).Clone(),
+		Body:          io.NopCloser(bytes.NewReader(recorded.Body.bytes())),
+		ContentLength: int64(len(recorded.Body.bytes())),
+		Request:       req,
+	}, nil
+}
+
+// readAndRestoreBody reads a request or response body and replaces it with
+// an unread copy.
+func readAndRestoreBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+
+	data, err := io.ReadAll(*body)
+	if err != nil {
+		return nil, err
+	}
+	if err := (*body).Close(); err != nil {
+		return nil, err
+	}
+
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+func readCassette(path string) (*cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("unable to parse cassette %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+func writeCassette(path string, c *cassette) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that an interrupted run never
+	// leaves a truncated cassette behind
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/albertocavalcante/terraform-provider-lambda/internal/fakelambda"
+)
+
+func TestCassetteRecordReplay(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	fake := fakelambda.New(fakelambda.Options{})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	recorder, err := newCassetteRecorder(path, http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recording := &ProviderConfig{
+		ApiKey:     "super-secret-token",
+		Endpoint:   server.URL,
+		HTTPClient: &http.Client{Transport: recorder},
+	}
+
+	created, err := recording.CreateSshKey(ctx, CreateSshKeyRequest{Name: "recorded"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.PrivateKey == "" {
+		t.Fatal("expected the recorded call to still return the private key")
+	}
+
+	recordedKeys, err := recording.ListSshKeys(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = recording.GetInstance(ctx, "missing")
+	if !isNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"super-secret-token", created.PrivateKey, "PRIVATE KEY"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains secret %q", secret)
+		}
+	}
+
+	// Replay with the API gone
+	server.Close()
+
+	replayer, err := newCassetteReplayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replaying := &ProviderConfig{
+		ApiKey:     redacted,
+		Endpoint:   "http://replay.invalid",
+		HTTPClient: &http.Client{Transport: replayer},
+	}
+
+	replayed, err := replaying.CreateSshKey(ctx, CreateSshKeyRequest{Name: "recorded"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.Id != created.Id || replayed.PublicKey != created.PublicKey || replayed.PrivateKey != redacted {
+		t.Errorf("unexpected replayed key %+v", replayed)
+	}
+
+	// Requests can be repeated once their recordings are used up
+	for i := 0; i < 2; i++ {
+		keys, err := replaying.ListSshKeys(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != len(recordedKeys) || keys[0].Id != recordedKeys[0].Id {
+			t.Errorf("unexpected replayed keys %+v", keys)
+		}
+	}
+
+	_, err = replaying.GetInstance(ctx, "missing")
+	if !isNotFound(err) {
+		t.Errorf("expected the recorded not found error, got %v", err)
+	}
+
+	// The request body is part of the match
+	if _, err := replaying.CreateSshKey(ctx, CreateSshKeyRequest{Name: "other"}); err == nil || !strings.Contains(err.Error(), "no recorded response") {
+		t.Errorf("expected an unmatched request to fail, got %v", err)
+	}
+}
+
+func TestCassetteRecorderAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	fake := fakelambda.New(fakelambda.Options{})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	// Each Terraform command runs in a new provider process
+	for i := 0; i < 2; i++ {
+		recorder, err := newCassetteRecorder(path, http.DefaultTransport)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		client := &ProviderConfig{ApiKey: "token", Endpoint: server.URL, HTTPClient: &http.Client{Transport: recorder}}
+		if _, err := client.ListSshKeys(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	recorded, err := readCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded.Interactions) != 2 {
+		t.Errorf("expected 2 interactions, got %d", len(recorded.Interactions))
+	}
+}
@@ -0,0 +1,232 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	envCatalogCacheTTL  = "LAMBDA_CLOUD_CATALOG_CACHE_TTL"
+	envCatalogCacheFile = "LAMBDA_CLOUD_CATALOG_CACHE_FILE"
+)
+
+// defaultCatalogCacheTTL is how long the instance type catalog is reused. It
+// is long enough to cover a typical apply, so that every resource prices and
+// checks capacity against the same catalog.
+const defaultCatalogCacheTTL = 5 * time.Minute
+
+// catalogCacheSettings holds the resolved catalog cache attributes of the
+// provider block.
+type catalogCacheSettings struct {
+	TTL  time.Duration
+	File string
+}
+
+// catalogCacheSettings validates and returns the catalog cache settings,
+// falling back to environment variables and defaults.
+func (m *LambdaProviderModel) catalogCacheSettings() (catalogCacheSettings, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	settings := catalogCacheSettings{
+		TTL:  defaultCatalogCacheTTL,
+		File: stringSetting(m.CatalogCacheFile, envCatalogCacheFile, ""),
+	}
+
+	if ttl := stringSetting(m.CatalogCacheTTL, envCatalogCacheTTL, ""); ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err == nil && d <= 0 {
+			err = fmt.Errorf("duration must be greater than zero")
+		}
+		if err != nil {
+			diags.AddAttributeError(path.Root("catalog_cache_ttl"), "Invalid catalog cache TTL",
+				fmt.Sprintf("catalog_cache_ttl must be a positive duration such as \"5m\", got %q: %s", ttl, err))
+		}
+		settings.TTL = d
+	}
+
+	return settings, diags
+}
+
+// catalogCache holds the instance type catalog so that validators, spend
+// checks and the instance types data source share one fetch, and one view of
+// prices and capacity. Concurrent lookups that find no fresh catalog share
+// one API call. With a file set, the catalog is kept on disk for later runs
+// against the same endpoint.
+type catalogCache struct {
+	ttl      time.Duration
+	file     string
+	endpoint string
+	now      func() time.Time
+	fetch    func(context.Context) (map[string]InstanceTypeAPIResponse, error)
+
+	group singleflight.Group
+
+	mu            sync.Mutex
+	instanceTypes map[string]InstanceTypeAPIResponse
+	fetchedAt     time.Time
+}
+
+// catalogCacheFile is the on-disk form of the catalog cache.
+type catalogCacheFile struct {
+	Endpoint      string                             `json:"endpoint"`
+	FetchedAt     time.Time                          `json:"fetched_at"`
+	InstanceTypes map[string]InstanceTypeAPIResponse `json:"instance_types"`
+}
+
+func newCatalogCache(settings catalogCacheSettings, endpoint string, fetch func(context.Context) (map[string]InstanceTypeAPIResponse, error)) *catalogCache {
+	return &catalogCache{
+		ttl:      settings.TTL,
+		file:     settings.File,
+		endpoint: endpoint,
+		now:      time.Now,
+		fetch:    fetch,
+	}
+}
+
+// get returns a fresh catalog, loading it from the cache file or fetching it
+// if needed.
+func (c *catalogCache) get(ctx context.Context) (map[string]InstanceTypeAPIResponse, error) {
+	if instanceTypes := c.fresh(); instanceTypes != nil {
+		return instanceTypes, nil
+	}
+
+	result, err, _ := c.group.Do("instance-types", func() (interface{}, error) {
+		// Another call may have refreshed the catalog meanwhile
+		if instanceTypes := c.fresh(); instanceTypes != nil {
+			return instanceTypes, nil
+		}
+
+		if cached, ok := c.load(ctx); ok {
+			c.store(cached.InstanceTypes, cached.FetchedAt)
+			tflog.Debug(ctx, "Using cached instance type catalog", map[string]interface{}{
+				"file": c.file,
+				"age":  c.now().Sub(cached.FetchedAt).Round(time.Second).String(),
+			})
+			return cached.InstanceTypes, nil
+		}
+
+		fetchedAt := c.now()
+		instanceTypes, err := c.fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c.store(instanceTypes, fetchedAt)
+		c.save(ctx, instanceTypes, fetchedAt)
+
+		tflog.Debug(ctx, "Fetched instance type catalog", map[string]interface{}{"count": len(instanceTypes)})
+
+		return instanceTypes, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.(map[string]InstanceTypeAPIResponse), nil
+}
+
+// fresh returns the cached catalog, or nil if there is none or it is older
+// than the TTL.
+func (c *catalogCache) fresh() map[string]InstanceTypeAPIResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.instanceTypes == nil || c.now().Sub(c.fetchedAt) >= c.ttl {
+		return nil
+	}
+	return c.instanceTypes
+}
+
+func (c *catalogCache) store(instanceTypes map[string]InstanceTypeAPIResponse, fetchedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.instanceTypes, c.fetchedAt = instanceTypes, fetchedAt
+}
+
+// load reads the cache file, reporting whether it holds a catalog of the same
+// endpoint that is younger than the TTL. A missing or unreadable file is a
+// cache miss.
+func (c *catalogCache) load(ctx context.Context) (catalogCacheFile, bool) {
+	var cached catalogCacheFile
+	if c.file == "" {
+		return cached, false
+	}
+
+	data, err := os.ReadFile(c.file)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			tflog.Warn(ctx, "Unable to read the catalog cache file", map[string]interface{}{"file": c.file, "error": err.Error()})
+		}
+		return cached, false
+	}
+	if err := json.Unmarshal(data, &cached); err != nil {
+		tflog.Warn(ctx, "Unable to parse the catalog cache file", map[string]interface{}{"file": c.file, "error": err.Error()})
+		return cached, false
+	}
+
+	age := c.now().Sub(cached.FetchedAt)
+	if cached.Endpoint != c.endpoint || cached.InstanceTypes == nil || age < 0 || age >= c.ttl {
+		return cached, false
+	}
+	return cached, true
+}
+
+// save writes the catalog to the cache file. Failing to do so only costs the
+// next run a fetch, so it is logged rather than reported.
+func (c *catalogCache) save(ctx context.Context, instanceTypes map[string]InstanceTypeAPIResponse, fetchedAt time.Time) {
+	if c.file == "" {
+		return
+	}
+
+	data, err := json.MarshalIndent(catalogCacheFile{
+		Endpoint:      c.endpoint,
+		FetchedAt:     fetchedAt.UTC(),
+		InstanceTypes: instanceTypes,
+	}, "", "  ")
+	if err == nil {
+		// Write to a temporary file first so that concurrent runs never
+		// read a truncated catalog
+		tmp := c.file + ".tmp"
+		if err = os.WriteFile(tmp, append(data, '\n'), 0o600); err == nil {
+			err = os.Rename(tmp, c.file)
+		}
+	}
+	if err != nil {
+		tflog.Warn(ctx, "Unable to write the catalog cache file", map[string]interface{}{"file": c.file, "error": err.Error()})
+	}
+}
+
+// instanceTypes returns the instance type catalog, from the snapshot or the
+// cache when the provider has one.
+func (c *ProviderConfig) instanceTypes(ctx context.Context) (map[string]InstanceTypeAPIResponse, error) {
+	switch {
+	case c.snapshot != nil:
+		return c.snapshot.InstanceTypes, nil
+	case c.catalog == nil:
+		return c.ListInstanceTypes(ctx)
+	}
+	return c.catalog.get(ctx)
+}
+
+// images returns the images available to launch instances from, from the
+// snapshot when the provider has one.
+func (c *ProviderConfig) images(ctx context.Context) ([]Image, error) {
+	if c.snapshot == nil {
+		return c.ListImages(ctx)
+	}
+	if c.snapshot.Images == nil {
+		return nil, fmt.Errorf("the catalog snapshot taken at %s has no images, take a new snapshot", c.snapshot.TakenAt.Format(time.RFC3339))
+	}
+	return c.snapshot.Images, nil
+}
@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/albertocavalcante/terraform-provider-lambda/internal/fakelambda"
+)
+
+// countCatalogReads returns how many instance type catalog calls fake served.
+func countCatalogReads(fake *fakelambda.Server) int {
+	var reads int
+	for _, req := range fake.Requests() {
+		if req.Method == http.MethodGet && req.Path == "/api/v1/instance-types" {
+			reads++
+		}
+	}
+	return reads
+}
+
+func TestCatalogCache(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T, file string) (*ProviderConfig, *fakelambda.Server) {
+		t.Helper()
+
+		fake := fakelambda.New(fakelambda.Options{})
+		server := httptest.NewServer(fake)
+		t.Cleanup(server.Close)
+
+		client := &ProviderConfig{ApiKey: "test", Endpoint: server.URL, HTTPClient: &http.Client{}}
+		client.catalog = newCatalogCache(catalogCacheSettings{TTL: time.Minute, File: file}, server.URL, client.ListInstanceTypes)
+		return client, fake
+	}
+
+	t.Run("concurrent lookups share one call", func(t *testing.T) {
+		client, fake := setup(t, "")
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				instanceTypes, err := client.instanceTypes(ctx)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if _, ok := instanceTypes["gpu_1x_a10"]; !ok {
+					t.Error("expected gpu_1x_a10 in the catalog")
+				}
+			}()
+		}
+		wg.Wait()
+
+		if got := countCatalogReads(fake); got != 1 {
+			t.Errorf("expected 1 catalog call, got %d", got)
+		}
+	})
+
+	t.Run("the catalog expires", func(t *testing.T) {
+		client, fake := setup(t, "")
+		now := time.Now()
+		client.catalog.now = func() time.Time { return now }
+
+		for i := 0; i < 2; i++ {
+			if _, err := client.instanceTypes(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+		now = now.Add(time.Minute)
+		if _, err := client.instanceTypes(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := countCatalogReads(fake); got != 2 {
+			t.Errorf("expected 2 catalog calls, got %d", got)
+		}
+	})
+
+	t.Run("failures are not cached", func(t *testing.T) {
+		client, fake := setup(t, "")
+		fake.InjectFault(fakelambda.ServerErrorFault(1, http.StatusInternalServerError))
+
+		if _, err := client.instanceTypes(ctx); err == nil {
+			t.Fatal("expected the first lookup to fail")
+		}
+		if _, err := client.instanceTypes(ctx); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("the cache file serves later runs", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "catalog.json")
+
+		first, fake := setup(t, file)
+		if _, err := first.instanceTypes(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		// A new provider against the same endpoint reads the file
+		second := &ProviderConfig{ApiKey: "test", Endpoint: first.Endpoint, HTTPClient: &http.Client{}}
+		second.catalog = newCatalogCache(catalogCacheSettings{TTL: time.Minute, File: file}, first.Endpoint, second.ListInstanceTypes)
+		instanceTypes, err := second.instanceTypes(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := instanceTypes["gpu_1x_a10"]; !ok {
+			t.Error("expected gpu_1x_a10 in the cached catalog")
+		}
+		if got := countCatalogReads(fake); got != 1 {
+			t.Errorf("expected 1 catalog call, got %d", got)
+		}
+
+		// A stale file is fetched again
+		second.catalog = newCatalogCache(catalogCacheSettings{TTL: time.Minute, File: file}, first.Endpoint, second.ListInstanceTypes)
+		second.catalog.now = func() time.Time { return time.Now().Add(time.Hour) }
+		if _, err := second.instanceTypes(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if got := countCatalogReads(fake); got != 2 {
+			t.Errorf("expected 2 catalog calls, got %d", got)
+		}
+	})
+
+	t.Run("a cache file of another endpoint is ignored", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "catalog.json")
+
+		client, _ := setup(t, file)
+		if _, err := client.instanceTypes(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		other, fake := setup(t, file)
+		if _, err := other.instanceTypes(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if got := countCatalogReads(fake); got != 1 {
+			t.Errorf("expected the other endpoint to serve 1 catalog call, got %d", got)
+		}
+	})
+
+	t.Run("an unreadable cache file is a miss", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "catalog.json")
+		if err := os.WriteFile(file, []byte("not json"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		client, fake := setup(t, file)
+		if _, err := client.instanceTypes(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if got := countCatalogReads(fake); got != 1 {
+			t.Errorf("expected 1 catalog call, got %d", got)
+		}
+	})
+}
@@ -0,0 +1,228 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const envCatalogSnapshotFile = "LAMBDA_CLOUD_CATALOG_SNAPSHOT_FILE"
+
+// CatalogSnapshot is a copy of the API's catalog of instance types, regions
+// and images, taken so that plans can run without API access. Instance types
+// and images are served in place of the API, and planned regions are checked
+// against the regions.
+type CatalogSnapshot struct {
+	Endpoint      string                             `json:"endpoint"`
+	TakenAt       time.Time                          `json:"taken_at"`
+	InstanceTypes map[string]InstanceTypeAPIResponse `json:"instance_types"`
+	Regions       []Region                           `json:"regions"`
+	Images        []Image                            `json:"images"`
+}
+
+// CatalogSnapshotOptions configures WriteCatalogSnapshot.
+type CatalogSnapshotOptions struct {
+	// Version is the provider version sent in the User-Agent.
+	Version string
+
+	// Profile selects a profile of the shared credentials file, like the
+	// profile attribute of the provider block.
+	Profile string
+}
+
+// WriteCatalogSnapshot takes a snapshot of the live catalog and writes it to
+// file. The API key and HTTP settings are found as the provider finds them
+// when none are configured: from environment variables and the shared
+// credentials file.
+func WriteCatalogSnapshot(ctx context.Context, file string, opts CatalogSnapshotOptions) error {
+	var data LambdaProviderModel
+	if opts.Profile != "" {
+		data.Profile = types.StringValue(opts.Profile)
+	}
+
+	creds, diags := data.credentials(ctx)
+	settings, settingsDiags := data.httpSettings()
+	diags.Append(settingsDiags...)
+	if diags.HasError() {
+		return diagnosticsError(diags)
+	}
+	if creds.ApiKey == "" {
+		return fmt.Errorf("no API key found, set %s or configure a profile", envAPIKey)
+	}
+
+	p := &LambdaProvider{version: opts.Version}
+	client, err := newHTTPClient(settings, p.userAgent("", settings), nil)
+	if err != nil {
+		return err
+	}
+	if creds.process != nil {
+		client.Transport = &credentialProcessTransport{next: client.Transport, process: creds.process}
+	}
+
+	config := &ProviderConfig{ApiKey: creds.ApiKey, Endpoint: settings.Endpoint, HTTPClient: client}
+	snapshot, err := config.takeCatalogSnapshot(ctx)
+	if err != nil {
+		return err
+	}
+	return writeCatalogSnapshot(file, snapshot)
+}
+
+// takeCatalogSnapshot fetches the instance types and images and gathers the
+// regions they mention. The API has no list of regions, and images are
+// published in every region whether or not it has capacity.
+func (c *ProviderConfig) takeCatalogSnapshot(ctx context.Context) (*CatalogSnapshot, error) {
+	takenAt := time.Now().UTC()
+
+	instanceTypes, err := c.ListInstanceTypes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read instance types: %w", err)
+	}
+
+	images, err := c.ListImages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read images: %w", err)
+	}
+
+	return &CatalogSnapshot{
+		Endpoint:      c.Endpoint,
+		TakenAt:       takenAt,
+		InstanceTypes: instanceTypes,
+		Regions:       catalogRegions(instanceTypes, images),
+		Images:        images,
+	}, nil
+}
+
+// catalogRegions returns every region that an instance type has capacity in
+// or an image is available in, sorted by name.
+func catalogRegions(instanceTypes map[string]InstanceTypeAPIResponse, images []Image) []Region {
+	byName := make(map[string]Region)
+	for _, instanceType := range instanceTypes {
+		for _, region := range instanceType.RegionsWithCapacityAvailable {
+			byName[region.Name] = region
+		}
+	}
+	for _, image := range images {
+		byName[image.Region.Name] = image.Region
+	}
+
+	regions := make([]Region, 0, len(byName))
+	for _, region := range byName {
+		regions = append(regions, region)
+	}
+	sort.Slice(regions, func(i, j int) bool { return regions[i].Name < regions[j].Name })
+	return regions
+}
+
+// checkRegion reports an error at attrPath if the region is not in the
+// snapshot, which would fail the launch once the plan is applied.
+func (s *CatalogSnapshot) checkRegion(attrPath path.Path, regionName string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	regionNames := make([]string, 0, len(s.Regions))
+	for _, region := range s.Regions {
+		regionNames = append(regionNames, region.Name)
+	}
+	if containsString(regionNames, regionName) {
+		return diags
+	}
+
+	diags.AddAttributeError(
+		attrPath,
+		"Region not in catalog snapshot",
+		fmt.Sprintf("Region %q is not in the catalog snapshot taken at %s. Known regions: %s. "+
+			"If the region was added since, take a new snapshot.",
+			regionName, s.TakenAt.Format(time.RFC3339), strings.Join(regionNames, ", ")),
+	)
+	return diags
+}
+
+// checkRegion enforces allowed_regions and, when planning from a catalog
+// snapshot, that the snapshot knows the region.
+func (c *ProviderConfig) checkRegion(attrPath path.Path, regionName string) diag.Diagnostics {
+	diags := c.Policy.checkRegion(attrPath, regionName)
+	if c.snapshot != nil {
+		diags.Append(c.snapshot.checkRegion(attrPath, regionName)...)
+	}
+	return diags
+}
+
+func readCatalogSnapshot(path string) (*CatalogSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshot CatalogSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("unable to parse catalog snapshot %s: %w", path, err)
+	}
+	if snapshot.InstanceTypes == nil || snapshot.TakenAt.IsZero() {
+		return nil, fmt.Errorf("%s is not a catalog snapshot: it lacks instance_types or taken_at", path)
+	}
+	return &snapshot, nil
+}
+
+func writeCatalogSnapshot(path string, snapshot *CatalogSnapshot) error {
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that an interrupted run never
+	// leaves a truncated snapshot behind
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// snapshotWarning describes the snapshot in use and how old it is, since its
+// prices and capacity may no longer hold.
+func snapshotWarning(path string, snapshot *CatalogSnapshot, now time.Time) string {
+	return fmt.Sprintf(
+		"Instance types and images are served from the catalog snapshot %s, taken %s ago from %s. "+
+			"Prices and capacity may have changed since; take a new snapshot to refresh them.",
+		path, formatAge(now.Sub(snapshot.TakenAt)), snapshot.Endpoint,
+	)
+}
+
+// formatAge renders a duration in its largest whole unit, such as "3 days".
+func formatAge(d time.Duration) string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s", unit)
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+
+	switch {
+	case d < time.Minute:
+		return "less than a minute"
+	case d < time.Hour:
+		return plural(int(d.Minutes()), "minute")
+	case d < 48*time.Hour:
+		return plural(int(d.Hours()), "hour")
+	default:
+		return plural(int(d.Hours()/24), "day")
+	}
+}
+
+// diagnosticsError joins the errors among diags into one error, for callers
+// outside Terraform.
+func diagnosticsError(diags diag.Diagnostics) error {
+	var errs []error
+	for _, d := range diags.Errors() {
+		errs = append(errs, fmt.Errorf("%s: %s", d.Summary(), d.Detail()))
+	}
+	return errors.Join(errs...)
+}
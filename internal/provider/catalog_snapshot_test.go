@@ -0,0 +1,230 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+
+	"github.com/albertocavalcante/terraform-provider-lambda/internal/fakelambda"
+)
+
+// writeTestCatalogSnapshot writes a snapshot of a fresh API stand-in's catalog
+// and returns its path. The stand-in is gone once it returns.
+func writeTestCatalogSnapshot(t *testing.T) string {
+	t.Helper()
+
+	isolateCredentials(t)
+
+	fake := fakelambda.New(fakelambda.Options{APIKey: "snapshot-key"})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	t.Setenv(envAPIKey, "snapshot-key")
+	t.Setenv(envEndpoint, server.URL)
+	t.Setenv(envAllowInsecureHTTP, "true")
+
+	file := filepath.Join(t.TempDir(), "catalog.json")
+	if err := WriteCatalogSnapshot(context.Background(), file, CatalogSnapshotOptions{Version: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestWriteCatalogSnapshot(t *testing.T) {
+	file := writeTestCatalogSnapshot(t)
+
+	snapshot, err := readCatalogSnapshot(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := snapshot.InstanceTypes["gpu_1x_a10"]; !ok {
+		t.Error("expected gpu_1x_a10 in the snapshot")
+	}
+	if time.Since(snapshot.TakenAt) > time.Minute {
+		t.Errorf("expected the snapshot to be taken just now, got %s", snapshot.TakenAt)
+	}
+
+	var regionNames []string
+	for _, region := range snapshot.Regions {
+		regionNames = append(regionNames, region.Name)
+	}
+	if !containsString(regionNames, "us-east-1") || !containsString(regionNames, "test-east-1") {
+		t.Errorf("expected the regions of instance types and images, got %v", regionNames)
+	}
+	if len(snapshot.Images) == 0 {
+		t.Error("expected the images in the snapshot")
+	}
+}
+
+func TestWriteCatalogSnapshotRequiresAPIKey(t *testing.T) {
+	isolateCredentials(t)
+
+	err := WriteCatalogSnapshot(context.Background(), filepath.Join(t.TempDir(), "catalog.json"), CatalogSnapshotOptions{})
+	if err == nil {
+		t.Fatal("expected an error without an API key")
+	}
+}
+
+func TestReadCatalogSnapshot(t *testing.T) {
+	for name, content := range map[string]string{
+		"not JSON":          "not json",
+		"no instance types": `{"taken_at": "2026-01-01T00:00:00Z"}`,
+		"no timestamp":      `{"instance_types": {}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "catalog.json")
+			writeTestFile(t, file, content)
+
+			if _, err := readCatalogSnapshot(file); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestCatalogSnapshotCheckRegion(t *testing.T) {
+	snapshot := &CatalogSnapshot{
+		TakenAt: time.Date(2026, time.January, 2, 3, 4, 5, 0, time.UTC),
+		Regions: []Region{{Name: "us-east-1"}, {Name: "us-west-1"}},
+	}
+
+	if diags := snapshot.checkRegion(path.Root("region_name"), "us-west-1"); diags.HasError() {
+		t.Errorf("expected a known region to pass, got %v", diags)
+	}
+
+	diags := snapshot.checkRegion(path.Root("region_name"), "me-west-1")
+	if !diags.HasError() {
+		t.Fatal("expected an error for a region missing from the snapshot")
+	}
+	if detail := diags[0].Detail(); !strings.Contains(detail, "2026-01-02T03:04:05Z") || !strings.Contains(detail, "us-east-1, us-west-1") {
+		t.Errorf("expected the snapshot time and known regions in %q", detail)
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		10 * time.Second:     "less than a minute",
+		time.Minute:          "1 minute",
+		45 * time.Minute:     "45 minutes",
+		25 * time.Hour:       "25 hours",
+		3*24*time.Hour + 5:   "3 days",
+		365 * 24 * time.Hour: "365 days",
+	} {
+		if got := formatAge(d); got != want {
+			t.Errorf("formatAge(%s) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestAccInstanceTypesDataSource_catalogSnapshot(t *testing.T) {
+	env := testAccPreCheck(t)
+	env.skipUnlessFake(t)
+
+	file := writeTestCatalogSnapshot(t)
+	t.Setenv(envAPIKey, "")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				// The endpoint the snapshot was taken from is gone and
+				// there is no API key
+				Config: fmt.Sprintf(`
+provider "lambda" {
+  endpoint              = "http://127.0.0.1:1"
+  allow_insecure_http   = true
+  catalog_snapshot_file = %q
+}
+
+data "lambda_instance_types" "test" {}
+`, file),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue(
+						"data.lambda_instance_types.test",
+						tfjsonpath.New("instance_types").AtMapKey("gpu_1x_a10").AtMapKey("price_cents_per_hour"),
+						knownvalue.Int64Exact(75),
+					),
+				},
+			},
+		},
+	})
+}
+
+func TestAccImagesDataSource_catalogSnapshot(t *testing.T) {
+	env := testAccPreCheck(t)
+	env.skipUnlessFake(t)
+
+	file := writeTestCatalogSnapshot(t)
+	t.Setenv(envAPIKey, "")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "lambda" {
+  endpoint              = "http://127.0.0.1:1"
+  allow_insecure_http   = true
+  catalog_snapshot_file = %q
+}
+
+data "lambda_images" "test" {
+  region_name = "us-east-1"
+}
+`, file),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("data.lambda_images.test", tfjsonpath.New("images"), knownvalue.ListSizeExact(2)),
+					statecheck.ExpectKnownValue(
+						"data.lambda_images.test",
+						tfjsonpath.New("images").AtSliceIndex(0).AtMapKey("name"),
+						knownvalue.StringExact("lambda-stack-24-04"),
+					),
+				},
+			},
+		},
+	})
+}
+
+func TestAccInstanceResource_catalogSnapshotRegion(t *testing.T) {
+	env := testAccPreCheck(t)
+	env.skipUnlessFake(t)
+
+	file := writeTestCatalogSnapshot(t)
+	t.Setenv(envAPIKey, "")
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				// The snapshot has no capacity or images in me-west-1
+				Config: fmt.Sprintf(`
+provider "lambda" {
+  endpoint              = "http://127.0.0.1:1"
+  allow_insecure_http   = true
+  catalog_snapshot_file = %q
+}
+
+resource "lambda_instance" "test" {
+  region_name        = "me-west-1"
+  instance_type_name = "gpu_1x_a10"
+  ssh_key_names      = ["deploy"]
+}
+`, file),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile(`Region not in catalog snapshot`),
+			},
+		},
+	})
+}
@@ -0,0 +1,440 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Environment variables for the credential chain.
+const (
+	envAPIKey                = "LAMBDA_CLOUD_API_KEY"
+	envAPIKeyFile            = "LAMBDA_CLOUD_API_KEY_FILE"
+	envProfile               = "LAMBDA_PROFILE"
+	envSharedCredentialsFile = "LAMBDA_SHARED_CREDENTIALS_FILE"
+	envCredentialProcess     = "LAMBDA_CLOUD_CREDENTIAL_PROCESS"
+
+	envSkipCredentialsValidation = "LAMBDA_CLOUD_SKIP_CREDENTIALS_VALIDATION"
+)
+
+const defaultProfile = "default"
+
+// credentialProcessRefreshWindow is how long before its expiry a key from a
+// credential process is replaced, so that it does not expire mid-request.
+const credentialProcessRefreshWindow = time.Minute
+
+// credentials is the API key the provider authenticates with and where it came
+// from. process is set when the key comes from a credential process, which is
+// run again when the key expires.
+type credentials struct {
+	ApiKey  string
+	Source  string
+	process *credentialProcess
+}
+
+// credentialSources are the settings of the credential chain that say where
+// the API key comes from, either all from the provider block or all from the
+// environment. The *Source fields name them in messages.
+type credentialSources struct {
+	ApiKey            string
+	ApiKeyFile        string
+	Profile           string
+	CredentialProcess string
+
+	ApiKeySource            string
+	ApiKeyFileSource        string
+	CredentialProcessSource string
+}
+
+// credentials resolves the API key through the credential chain: api_key, then
+// api_key_file, then a named profile, then credential_process, each from the
+// provider block; then the same from the environment, starting with
+// LAMBDA_CLOUD_API_KEY; and finally the default profile. It returns empty
+// credentials when none of them is set.
+func (m *LambdaProviderModel) credentials(ctx context.Context) (credentials, diag.Diagnostics) {
+	credentialsFile := stringSetting(m.SharedCredentialsFile, envSharedCredentialsFile, "")
+	if credentialsFile == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			credentialsFile = filepath.Join(home, ".lambda", "credentials")
+		}
+	}
+
+	// Anything set in the provider block wins over the environment
+	for _, sources := range []credentialSources{
+		{
+			ApiKey:                  m.ApiKey.ValueString(),
+			ApiKeyFile:              m.ApiKeyFile.ValueString(),
+			Profile:                 m.Profile.ValueString(),
+			CredentialProcess:       m.CredentialProcess.ValueString(),
+			ApiKeySource:            "api_key",
+			ApiKeyFileSource:        "api_key_file",
+			CredentialProcessSource: "credential_process",
+		},
+		{
+			ApiKey:                  os.Getenv(envAPIKey),
+			ApiKeyFile:              os.Getenv(envAPIKeyFile),
+			Profile:                 os.Getenv(envProfile),
+			CredentialProcess:       os.Getenv(envCredentialProcess),
+			ApiKeySource:            envAPIKey,
+			ApiKeyFileSource:        envAPIKeyFile,
+			CredentialProcessSource: envCredentialProcess,
+		},
+	} {
+		if creds, diags, ok := sources.credentials(ctx, credentialsFile); ok {
+			return creds, diags
+		}
+	}
+
+	return defaultProfileCredentials(ctx, credentialsFile)
+}
+
+// credentials returns the credentials from the first source that is set, and
+// whether any of them was.
+func (s credentialSources) credentials(ctx context.Context, credentialsFile string) (credentials, diag.Diagnostics, bool) {
+	var diags diag.Diagnostics
+
+	if s.ApiKey != "" {
+		return credentials{ApiKey: s.ApiKey, Source: s.ApiKeySource}, diags, true
+	}
+
+	if s.ApiKeyFile != "" {
+		apiKey, err := readAPIKeyFile(s.ApiKeyFile)
+		if err != nil {
+			diags.AddAttributeError(path.Root("api_key_file"), "Unable to read API key file", err.Error())
+			return credentials{}, diags, true
+		}
+		return credentials{ApiKey: apiKey, Source: s.ApiKeyFileSource}, diags, true
+	}
+
+	if s.Profile != "" {
+		creds, err := profileCredentials(ctx, credentialsFile, s.Profile)
+		if err != nil {
+			diags.AddAttributeError(path.Root("profile"), "Unable to load profile", err.Error())
+		}
+		return creds, diags, true
+	}
+
+	if s.CredentialProcess != "" {
+		process := newCredentialProcess(s.CredentialProcess)
+		apiKey, err := process.apiKey(ctx)
+		if err != nil {
+			diags.AddAttributeError(path.Root("credential_process"), "Credential process failed", err.Error())
+			return credentials{}, diags, true
+		}
+		return credentials{ApiKey: apiKey, Source: s.CredentialProcessSource, process: process}, diags, true
+	}
+
+	return credentials{}, diags, false
+}
+
+// defaultProfileCredentials returns the credentials of the default profile,
+// which is used when nothing else is configured, and silently skipped when
+// there is none.
+func defaultProfileCredentials(ctx context.Context, credentialsFile string) (credentials, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if _, err := os.Stat(credentialsFile); err == nil {
+		profiles, err := readCredentialsFile(credentialsFile)
+		if err != nil {
+			diags.AddError("Unable to read credentials file", err.Error())
+			return credentials{}, diags
+		}
+		if _, ok := profiles[defaultProfile]; ok {
+			creds, err := profileCredentials(ctx, credentialsFile, defaultProfile)
+			if err != nil {
+				diags.AddError("Unable to load profile", err.Error())
+			}
+			return creds, diags
+		}
+	}
+
+	return credentials{}, diags
+}
+
+// readAPIKeyFile returns the API key stored in a file, ignoring surrounding
+// whitespace.
+func readAPIKeyFile(file string) (string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	apiKey := strings.TrimSpace(string(data))
+	if apiKey == "" {
+		return "", fmt.Errorf("%s is empty", file)
+	}
+	return apiKey, nil
+}
+
+// profileCredentials returns the credentials of a profile in a credentials
+// file. A profile sets one of api_key, api_key_file or credential_process.
+func profileCredentials(ctx context.Context, file, profile string) (credentials, error) {
+	profiles, err := readCredentialsFile(file)
+	if err != nil {
+		return credentials{}, err
+	}
+
+	settings, ok := profiles[profile]
+	if !ok {
+		return credentials{}, fmt.Errorf("profile %q not found in %s", profile, file)
+	}
+
+	source := fmt.Sprintf("profile %q", profile)
+	switch {
+	case settings["api_key"] != "":
+		return credentials{ApiKey: settings["api_key"], Source: source}, nil
+	case settings["api_key_file"] != "":
+		apiKey, err := readAPIKeyFile(settings["api_key_file"])
+		if err != nil {
+			return credentials{}, fmt.Errorf("profile %q: %w", profile, err)
+		}
+		return credentials{ApiKey: apiKey, Source: source}, nil
+	case settings["credential_process"] != "":
+		process := newCredentialProcess(settings["credential_process"])
+		apiKey, err := process.apiKey(ctx)
+		if err != nil {
+			return credentials{}, fmt.Errorf("profile %q: %w", profile, err)
+		}
+		return credentials{ApiKey: apiKey, Source: source, process: process}, nil
+	}
+
+	return credentials{}, fmt.Errorf("profile %q in %s sets none of api_key, api_key_file or credential_process", profile, file)
+}
+
+// readCredentialsFile parses a credentials file, keyed by profile and then
+// setting. The format is INI, and the TOML subset it shares:
+//
+//	[default]
+//	api_key = "secret_..."
+//
+//	[ci]
+//	credential_process = vault-lambda-key --role ci
+func readCredentialsFile(file string) (map[string]map[string]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make(map[string]map[string]string)
+	var current map[string]string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := unquote(strings.TrimSpace(line[1 : len(line)-1]))
+			// Also accept the [profile name] form of AWS config files
+			name = strings.TrimSpace(strings.TrimPrefix(name, "profile "))
+			if profiles[name] == nil {
+				profiles[name] = make(map[string]string)
+			}
+			current = profiles[name]
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || current == nil {
+			return nil, fmt.Errorf("%s:%d: expected a [profile] header or a key = value setting", file, lineNumber)
+		}
+		current[strings.TrimSpace(key)] = unquote(strings.TrimSpace(value))
+	}
+
+	return profiles, scanner.Err()
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// credentialProcessOutput is what a credential process prints to stdout.
+type credentialProcessOutput struct {
+	ApiKey     string    `json:"api_key"`
+	Expiration time.Time `json:"expiration,omitempty"`
+}
+
+// credentialProcess runs a command that prints an API key, caching the key
+// until shortly before the expiration the command reports, if any.
+type credentialProcess struct {
+	command string
+	now     func() time.Time
+
+	mu         sync.Mutex
+	key        string
+	expiration time.Time
+}
+
+func newCredentialProcess(command string) *credentialProcess {
+	return &credentialProcess{command: command, now: time.Now}
+}
+
+// apiKey returns the current key, running the command if there is none yet or
+// the last one is about to expire.
+func (p *credentialProcess) apiKey(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.key != "" && (p.expiration.IsZero() || p.now().Add(credentialProcessRefreshWindow).Before(p.expiration)) {
+		return p.key, nil
+	}
+
+	args, err := splitCommand(p.command)
+	if err != nil {
+		return "", err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("unable to run credential process %q: %s: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	var output credentialProcessOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return "", fmt.Errorf("credential process %q printed invalid JSON: %s", args[0], err)
+	}
+	if output.ApiKey == "" {
+		return "", fmt.Errorf("credential process %q printed no api_key", args[0])
+	}
+
+	tflog.Debug(ctx, "Ran credential process", map[string]interface{}{
+		"command":    args[0],
+		"expiration": output.Expiration,
+	})
+
+	p.key, p.expiration = output.ApiKey, output.Expiration
+	return p.key, nil
+}
+
+// splitCommand splits a command line into arguments on spaces, honouring
+// single and double quotes. No shell is involved.
+func splitCommand(command string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	var quote rune
+	inArg := false
+
+	for _, r := range command {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			arg.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in credential process %q", command)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("credential process is empty")
+	}
+	return args, nil
+}
+
+// credentialProcessTransport authenticates requests with the current key of a
+// credential process, replacing the key set when the provider was configured.
+type credentialProcessTransport struct {
+	next    http.RoundTripper
+	process *credentialProcess
+}
+
+func (t *credentialProcessTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	apiKey, err := t.process.apiKey(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	return t.next.RoundTrip(req)
+}
+
+// validateCredentials makes a cheap authenticated call so that a bad API key
+// fails once, when the provider is configured, rather than on whichever
+// resource happens to call the API first.
+func (c *ProviderConfig) validateCredentials(ctx context.Context, source string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	_, err := c.ListSshKeys(ctx)
+	if err == nil {
+		return diags
+	}
+
+	const skipHint = " Set skip_credentials_validation to true to skip this check, for example when working offline."
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		diags.AddError(
+			"Unable to validate credentials",
+			fmt.Sprintf("Unable to reach the Lambda Cloud API at %s to validate the API key from %s, got error: %s.%s",
+				c.Endpoint, source, err, skipHint),
+		)
+		return diags
+	}
+
+	switch {
+	case apiErr.Code == errorCodeRevokedAPIKey || apiErr.Code == errorCodeAccountInactive:
+		diags.AddError(
+			"API key revoked",
+			fmt.Sprintf("The API key from %s was revoked or its account is no longer active: %s. "+
+				"Create a new key at https://cloud.lambda.ai/api-keys.", source, apiErr.Message),
+		)
+	case apiErr.Code == errorCodeInvalidAPIKey || apiErr.StatusCode == http.StatusUnauthorized:
+		diags.AddError(
+			"Invalid API key",
+			fmt.Sprintf("The API key from %s was not accepted: %s. "+
+				"Check that it was copied in full and belongs to the intended account.", source, apiErr.Message),
+		)
+	case apiErr.StatusCode == http.StatusForbidden:
+		diags.AddError(
+			"Insufficient API key permissions",
+			fmt.Sprintf("The API key from %s is valid but not allowed to use the API: %s. "+
+				"Use a key with access to instances, SSH keys and file systems.", source, apiErr.Message),
+		)
+	default:
+		diags.AddError(
+			"Unable to validate credentials",
+			fmt.Sprintf("Unable to validate the API key from %s, got error: %s.%s", source, err, skipHint),
+		)
+	}
+
+	return diags
+}
@@ -0,0 +1,343 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+
+	"github.com/albertocavalcante/terraform-provider-lambda/internal/fakelambda"
+)
+
+// isolateCredentials clears the credential environment variables and points
+// the shared credentials file at a temporary path, which it returns.
+func isolateCredentials(t *testing.T) string {
+	t.Helper()
+
+	for _, env := range []string{envAPIKey, envAPIKeyFile, envProfile, envCredentialProcess} {
+		t.Setenv(env, "")
+	}
+
+	file := filepath.Join(t.TempDir(), "credentials")
+	t.Setenv(envSharedCredentialsFile, file)
+	return file
+}
+
+func writeTestFile(t *testing.T, file, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testCredentialProcess writes a script that prints output and records each
+// run, returning the command to run it and the file counting the runs.
+func testCredentialProcess(t *testing.T, output string) (command, runs string) {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("Credential process tests use a shell script")
+	}
+
+	dir := t.TempDir()
+	runs = filepath.Join(dir, "runs")
+	script := filepath.Join(dir, "credential process.sh")
+	writeTestFile(t, script, fmt.Sprintf("#!/bin/sh\necho run >> %q\necho '%s'\n", runs, output))
+	if err := os.Chmod(script, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	return fmt.Sprintf("%q --role ci", script), runs
+}
+
+func countRuns(t *testing.T, runs string) int {
+	t.Helper()
+
+	data, err := os.ReadFile(runs)
+	if os.IsNotExist(err) {
+		return 0
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(data) / len("run\n")
+}
+
+func TestCredentialChain(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("api_key comes first", func(t *testing.T) {
+		file := isolateCredentials(t)
+		writeTestFile(t, file, "[default]\napi_key = from-profile\n")
+		t.Setenv(envAPIKey, "from-env")
+
+		creds, diags := (&LambdaProviderModel{ApiKey: types.StringValue("from-config")}).credentials(ctx)
+		if diags.HasError() || creds.ApiKey != "from-config" {
+			t.Fatalf("expected the configured key, got %q: %v", creds.ApiKey, diags)
+		}
+
+		creds, _ = (&LambdaProviderModel{}).credentials(ctx)
+		if creds.ApiKey != "from-env" {
+			t.Errorf("expected the key from the environment, got %q", creds.ApiKey)
+		}
+	})
+
+	t.Run("the provider block wins over the environment", func(t *testing.T) {
+		file := isolateCredentials(t)
+		writeTestFile(t, file, "[dev]\napi_key = from-profile\n")
+		keyFile := filepath.Join(t.TempDir(), "key")
+		writeTestFile(t, keyFile, "from-file")
+		envKeyFile := filepath.Join(t.TempDir(), "env-key")
+		writeTestFile(t, envKeyFile, "from-env-file")
+		command, _ := testCredentialProcess(t, `{"api_key": "from-process"}`)
+		t.Setenv(envAPIKey, "from-env")
+		t.Setenv(envAPIKeyFile, envKeyFile)
+
+		for _, tc := range []struct {
+			model  LambdaProviderModel
+			want   string
+			source string
+		}{
+			{model: LambdaProviderModel{ApiKeyFile: types.StringValue(keyFile)}, want: "from-file", source: "api_key_file"},
+			{model: LambdaProviderModel{Profile: types.StringValue("dev")}, want: "from-profile", source: `profile "dev"`},
+			{model: LambdaProviderModel{CredentialProcess: types.StringValue(command)}, want: "from-process", source: "credential_process"},
+			{model: LambdaProviderModel{}, want: "from-env", source: envAPIKey},
+		} {
+			creds, diags := tc.model.credentials(ctx)
+			if diags.HasError() || creds.ApiKey != tc.want || creds.Source != tc.source {
+				t.Errorf("expected %q from %s, got %q from %s: %v", tc.want, tc.source, creds.ApiKey, creds.Source, diags)
+			}
+		}
+
+		t.Setenv(envAPIKey, "")
+		creds, _ := (&LambdaProviderModel{}).credentials(ctx)
+		if creds.ApiKey != "from-env-file" || creds.Source != envAPIKeyFile {
+			t.Errorf("expected the key file from the environment, got %q from %s", creds.ApiKey, creds.Source)
+		}
+	})
+
+	t.Run("api_key_file", func(t *testing.T) {
+		isolateCredentials(t)
+		keyFile := filepath.Join(t.TempDir(), "key")
+		writeTestFile(t, keyFile, "  from-file\n")
+
+		creds, diags := (&LambdaProviderModel{ApiKeyFile: types.StringValue(keyFile)}).credentials(ctx)
+		if diags.HasError() || creds.ApiKey != "from-file" {
+			t.Fatalf("expected the key from the file, got %q: %v", creds.ApiKey, diags)
+		}
+
+		writeTestFile(t, keyFile, "\n")
+		if _, diags := (&LambdaProviderModel{ApiKeyFile: types.StringValue(keyFile)}).credentials(ctx); !diags.HasError() {
+			t.Error("expected an error for an empty key file")
+		}
+	})
+
+	t.Run("profiles", func(t *testing.T) {
+		file := isolateCredentials(t)
+		keyFile := filepath.Join(t.TempDir(), "key")
+		writeTestFile(t, keyFile, "from-profile-file")
+		writeTestFile(t, file, fmt.Sprintf(`# Lambda credentials
+[default]
+api_key = "from-default"
+
+[profile dev]
+api_key = 'from-dev'
+
+[ci]
+api_key_file = %s
+`, keyFile))
+
+		creds, diags := (&LambdaProviderModel{}).credentials(ctx)
+		if diags.HasError() || creds.ApiKey != "from-default" {
+			t.Fatalf("expected the default profile, got %q: %v", creds.ApiKey, diags)
+		}
+
+		creds, _ = (&LambdaProviderModel{Profile: types.StringValue("dev")}).credentials(ctx)
+		if creds.ApiKey != "from-dev" {
+			t.Errorf("expected the dev profile, got %q", creds.ApiKey)
+		}
+
+		t.Setenv(envProfile, "ci")
+		creds, _ = (&LambdaProviderModel{}).credentials(ctx)
+		if creds.ApiKey != "from-profile-file" {
+			t.Errorf("expected the ci profile from LAMBDA_PROFILE, got %q", creds.ApiKey)
+		}
+
+		if _, diags := (&LambdaProviderModel{Profile: types.StringValue("missing")}).credentials(ctx); !diags.HasError() {
+			t.Error("expected an error for a missing profile")
+		}
+	})
+
+	t.Run("credential_process comes before the default profile", func(t *testing.T) {
+		file := isolateCredentials(t)
+		writeTestFile(t, file, "[default]\napi_key = from-default\n")
+		command, _ := testCredentialProcess(t, `{"api_key": "from-process"}`)
+
+		creds, diags := (&LambdaProviderModel{CredentialProcess: types.StringValue(command)}).credentials(ctx)
+		if diags.HasError() || creds.ApiKey != "from-process" || creds.process == nil {
+			t.Fatalf("expected the key from the process, got %+v: %v", creds, diags)
+		}
+	})
+
+	t.Run("no credentials", func(t *testing.T) {
+		isolateCredentials(t)
+
+		creds, diags := (&LambdaProviderModel{}).credentials(ctx)
+		if diags.HasError() || creds.ApiKey != "" {
+			t.Errorf("expected no credentials, got %+v: %v", creds, diags)
+		}
+	})
+}
+
+func TestCredentialProcess(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("a key without expiry is cached", func(t *testing.T) {
+		command, runs := testCredentialProcess(t, `{"api_key": "secret"}`)
+		process := newCredentialProcess(command)
+
+		for i := 0; i < 3; i++ {
+			if key, err := process.apiKey(ctx); err != nil || key != "secret" {
+				t.Fatalf("expected the key, got %q: %v", key, err)
+			}
+		}
+		if got := countRuns(t, runs); got != 1 {
+			t.Errorf("expected 1 run, got %d", got)
+		}
+	})
+
+	t.Run("an expiring key is fetched again", func(t *testing.T) {
+		expiration := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+		command, runs := testCredentialProcess(t,
+			fmt.Sprintf(`{"api_key": "secret", "expiration": %q}`, expiration.Format(time.RFC3339)))
+
+		now := expiration.Add(-time.Hour)
+		process := newCredentialProcess(command)
+		process.now = func() time.Time { return now }
+
+		if _, err := process.apiKey(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := process.apiKey(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if got := countRuns(t, runs); got != 1 {
+			t.Fatalf("expected the key to be cached before it expires, got %d runs", got)
+		}
+
+		now = expiration.Add(-credentialProcessRefreshWindow / 2)
+		if _, err := process.apiKey(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if got := countRuns(t, runs); got != 2 {
+			t.Errorf("expected the process to run again near expiry, got %d runs", got)
+		}
+	})
+
+	t.Run("invalid output", func(t *testing.T) {
+		command, _ := testCredentialProcess(t, `{"token": "secret"}`)
+		if _, err := newCredentialProcess(command).apiKey(ctx); err == nil {
+			t.Error("expected an error when the output has no api_key")
+		}
+	})
+}
+
+func TestSplitCommand(t *testing.T) {
+	args, err := splitCommand(`vault read  "secret/lambda key" -field='api key'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"vault", "read", "secret/lambda key", "-field=api key"}
+	if fmt.Sprint(args) != fmt.Sprint(want) {
+		t.Errorf("splitCommand() = %q, want %q", args, want)
+	}
+
+	for _, command := range []string{"", "   ", `echo "unterminated`} {
+		if _, err := splitCommand(command); err == nil {
+			t.Errorf("splitCommand(%q): expected an error", command)
+		}
+	}
+}
+
+func TestValidateCredentials(t *testing.T) {
+	ctx := context.Background()
+
+	cases := []struct {
+		name    string
+		apiKey  string
+		fault   *fakelambda.Fault
+		summary string
+	}{
+		{name: "valid", apiKey: "tf-acc-api-key"},
+		{name: "invalid", apiKey: "wrong", summary: "Invalid API key"},
+		{
+			name:    "revoked",
+			apiKey:  "tf-acc-api-key",
+			fault:   &fakelambda.Fault{StatusCode: http.StatusUnauthorized, Code: errorCodeRevokedAPIKey, Message: "API key was revoked."},
+			summary: "API key revoked",
+		},
+		{
+			name:    "revoked with another status",
+			apiKey:  "tf-acc-api-key",
+			fault:   &fakelambda.Fault{StatusCode: http.StatusForbidden, Code: errorCodeRevokedAPIKey, Message: "API key was revoked."},
+			summary: "API key revoked",
+		},
+		{
+			name:    "inactive account",
+			apiKey:  "tf-acc-api-key",
+			fault:   &fakelambda.Fault{StatusCode: http.StatusForbidden, Code: errorCodeAccountInactive, Message: "Account is inactive."},
+			summary: "API key revoked",
+		},
+		{
+			name:    "invalid API key code with another status",
+			apiKey:  "tf-acc-api-key",
+			fault:   &fakelambda.Fault{StatusCode: http.StatusForbidden, Code: errorCodeInvalidAPIKey, Message: "API key was invalid, expired, or deleted."},
+			summary: "Invalid API key",
+		},
+		{
+			name:    "forbidden",
+			apiKey:  "tf-acc-api-key",
+			fault:   &fakelambda.Fault{StatusCode: http.StatusForbidden, Code: "global/forbidden", Message: "Not allowed."},
+			summary: "Insufficient API key permissions",
+		},
+		{
+			name:    "server error",
+			apiKey:  "tf-acc-api-key",
+			fault:   &fakelambda.Fault{StatusCode: http.StatusInternalServerError, Code: fakelambda.ErrorCodeInternal},
+			summary: "Unable to validate credentials",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fake := fakelambda.New(fakelambda.Options{APIKey: "tf-acc-api-key"})
+			if c.fault != nil {
+				fake.InjectFault(*c.fault)
+			}
+			server := httptest.NewServer(fake)
+			defer server.Close()
+
+			config := &ProviderConfig{ApiKey: c.apiKey, Endpoint: server.URL, HTTPClient: &http.Client{}}
+			diags := config.validateCredentials(ctx, "api_key")
+
+			if c.summary == "" {
+				if diags.HasError() {
+					t.Fatal(diags)
+				}
+				return
+			}
+			if diags.ErrorsCount() != 1 || diags.Errors()[0].Summary() != c.summary {
+				t.Errorf("expected a single %q error, got %v", c.summary, diags)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+func NewImagesDataSource() datasource.DataSource {
+	return &ImagesDataSource{}
+}
+
+type ImagesDataSource struct {
+	client *ProviderConfig
+}
+
+func (d *ImagesDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_images"
+}
+
+func (d *ImagesDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Fetch the machine images available to launch Lambda Cloud instances from.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Data source identifier",
+			},
+			"region_name": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "Only list the images available in this region",
+			},
+			"images": schema.ListNestedAttribute{
+				Computed:            true,
+				MarkdownDescription: "List of available images",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The unique identifier (ID) of the image",
+						},
+						"name": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The name of the image",
+						},
+						"description": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "A description of the image",
+						},
+						"family": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The family the image belongs to",
+						},
+						"version": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The version of the image",
+						},
+						"architecture": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The CPU architecture of the image",
+						},
+						"region_name": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The region the image is available in",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *ImagesDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*ProviderConfig)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *ProviderConfig, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	d.client = client
+}
+
+// ImagesDataSourceModel describes the data source data model.
+type ImagesDataSourceModel struct {
+	Id         types.String `tfsdk:"id"`
+	RegionName types.String `tfsdk:"region_name"`
+	Images     []ImageData  `tfsdk:"images"`
+}
+
+// ImageData represents an image
+type ImageData struct {
+	Id           types.String `tfsdk:"id"`
+	Name         types.String `tfsdk:"name"`
+	Description  types.String `tfsdk:"description"`
+	Family       types.String `tfsdk:"family"`
+	Version      types.String `tfsdk:"version"`
+	Architecture types.String `tfsdk:"architecture"`
+	RegionName   types.String `tfsdk:"region_name"`
+}
+
+func (d *ImagesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data ImagesDataSourceModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Read the images, from the catalog snapshot when planning offline
+	images, err := d.client.images(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read images, got error: %s", err))
+		return
+	}
+
+	data.Images = []ImageData{}
+	for _, image := range images {
+		if !data.RegionName.IsNull() && image.Region.Name != data.RegionName.ValueString() {
+			continue
+		}
+
+		data.Images = append(data.Images, ImageData{
+			Id:           types.StringValue(image.Id),
+			Name:         types.StringValue(image.Name),
+			Description:  types.StringValue(image.Description),
+			Family:       types.StringValue(image.Family),
+			Version:      types.StringValue(image.Version),
+			Architecture: types.StringValue(image.Architecture),
+			RegionName:   types.StringValue(image.Region.Name),
+		})
+	}
+
+	data.Id = types.StringValue("images")
+
+	tflog.Trace(ctx, "read images data source")
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
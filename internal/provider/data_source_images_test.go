@@ -0,0 +1,33 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+)
+
+func TestAccImagesDataSource(t *testing.T) {
+	env := testAccPreCheck(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: env.providerConfig() + fmt.Sprintf(`
+data "lambda_images" "test" {
+  region_name = %q
+}
+`, env.RegionName),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("data.lambda_images.test", tfjsonpath.New("id"), knownvalue.StringExact("images")),
+					statecheck.ExpectKnownValue("data.lambda_images.test", tfjsonpath.New("images").AtSliceIndex(0).AtMapKey("region_name"), knownvalue.StringExact(env.RegionName)),
+					statecheck.ExpectKnownValue("data.lambda_images.test", tfjsonpath.New("images").AtSliceIndex(0).AtMapKey("family"), knownvalue.NotNull()),
+				},
+			},
+		},
+	})
+}
@@ -118,7 +118,19 @@ type InstanceTypesResponse struct {
 }
 
 type InstanceTypeAPIResponse struct {
-	InstanceType InstanceTypeDetails `json:"instance_type"`
+	InstanceType                 InstanceTypeDetails `json:"instance_type"`
+	RegionsWithCapacityAvailable []Region            `json:"regions_with_capacity_available"`
+}
+
+// HasCapacityIn reports whether the instance type currently has capacity in
+// the given region.
+func (t InstanceTypeAPIResponse) HasCapacityIn(regionName string) bool {
+	for _, region := range t.RegionsWithCapacityAvailable {
+		if region.Name == regionName {
+			return true
+		}
+	}
+	return false
 }
 
 type InstanceTypeDetails struct {
@@ -145,41 +157,16 @@ func (d *InstanceTypesDataSource) Read(ctx context.Context, req datasource.ReadR
 		return
 	}
 
-	// Make API call to get instance types
-	httpReq, err := http.NewRequestWithContext(ctx, "GET",
-		fmt.Sprintf("%s/api/v1/instance-types", d.client.Endpoint), nil)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create request, got error: %s", err))
-		return
-	}
-
-	d.client.AddAuthHeader(httpReq)
-
-	httpResp, err := d.client.HTTPClient.Do(httpReq)
+	// Read the instance type catalog shared across the run
+	instanceTypes, err := d.client.instanceTypes(ctx)
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read instance types, got error: %s", err))
 		return
 	}
-	defer func() {
-		if err := httpResp.Body.Close(); err != nil {
-			tflog.Warn(ctx, "Failed to close response body", map[string]interface{}{"error": err})
-		}
-	}()
-
-	if httpResp.StatusCode != http.StatusOK {
-		resp.Diagnostics.AddError("API Error", fmt.Sprintf("Instance types API returned status %d", httpResp.StatusCode))
-		return
-	}
-
-	var apiResp InstanceTypesResponse
-	if err := json.NewDecoder(httpResp.Body).Decode(&apiResp); err != nil {
-		resp.Diagnostics.AddError("Parse Error", fmt.Sprintf("Unable to parse instance types response, got error: %s", err))
-		return
-	}
 
 	// Convert API response to Terraform types
 	instanceTypesMap := make(map[string]InstanceTypeData)
-	for key, item := range apiResp.Data {
+	for key, item := range instanceTypes {
 		instanceTypesMap[key] = InstanceTypeData{
 			Name:              types.StringValue(item.InstanceType.Name),
 			Description:       types.StringValue(item.InstanceType.Description),
@@ -220,3 +207,36 @@ func (d *InstanceTypesDataSource) Read(ctx context.Context, req datasource.ReadR
 	// Save data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
+
+// ListInstanceTypes fetches the instance type catalog, keyed by instance type
+// name, including the regions that currently have capacity for each type.
+func (c *ProviderConfig) ListInstanceTypes(ctx context.Context) (map[string]InstanceTypeAPIResponse, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET",
+		fmt.Sprintf("%s/api/v1/instance-types", c.Endpoint), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c.AddAuthHeader(httpReq)
+
+	httpResp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := httpResp.Body.Close(); err != nil {
+			tflog.Warn(ctx, "Failed to close response body", map[string]interface{}{"error": err})
+		}
+	}()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, newAPIError("instance types", httpResp)
+	}
+
+	var apiResp InstanceTypesResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("unable to parse instance types response: %w", err)
+	}
+
+	return apiResp.Data, nil
+}
@@ -0,0 +1,40 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+)
+
+func TestAccInstanceTypesDataSource(t *testing.T) {
+	env := testAccPreCheck(t)
+
+	instanceType := tfjsonpath.New("instance_types").AtMapKey(env.InstanceTypeName)
+
+	checks := []statecheck.StateCheck{
+		statecheck.ExpectKnownValue("data.lambda_instance_types.test", tfjsonpath.New("id"), knownvalue.StringExact("instance_types")),
+		statecheck.ExpectKnownValue("data.lambda_instance_types.test", instanceType.AtMapKey("name"), knownvalue.StringExact(env.InstanceTypeName)),
+		statecheck.ExpectKnownValue("data.lambda_instance_types.test", instanceType.AtMapKey("gpus"), knownvalue.NotNull()),
+	}
+
+	// Prices on the real API change over time
+	if env.Fake != nil {
+		checks = append(checks,
+			statecheck.ExpectKnownValue("data.lambda_instance_types.test", instanceType.AtMapKey("price_cents_per_hour"), knownvalue.Int64Exact(75)),
+			statecheck.ExpectKnownValue("data.lambda_instance_types.test", instanceType.AtMapKey("gpus"), knownvalue.Int64Exact(1)),
+		)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:            env.providerConfig() + `data "lambda_instance_types" "test" {}`,
+				ConfigStateChecks: checks,
+			},
+		},
+	})
+}
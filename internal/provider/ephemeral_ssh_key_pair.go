@@ -0,0 +1,255 @@
+package provider
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/crypto/ssh"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ ephemeral.EphemeralResource = &SshKeyPairEphemeralResource{}
+var _ ephemeral.EphemeralResourceWithConfigure = &SshKeyPairEphemeralResource{}
+var _ ephemeral.EphemeralResourceWithClose = &SshKeyPairEphemeralResource{}
+
+// sshKeyPairPrivateKey is the private data key holding the key to delete on close.
+const sshKeyPairPrivateKey = "ssh_key_pair"
+
+// sshKeyPairComment marks the public keys of generated key pairs, so that a key
+// left behind by an earlier run can be told apart from one added elsewhere.
+const sshKeyPairComment = "terraform-lambda-ssh-key-pair"
+
+func NewSshKeyPairEphemeralResource() ephemeral.EphemeralResource {
+	return &SshKeyPairEphemeralResource{}
+}
+
+// SshKeyPairEphemeralResource generates an SSH key pair locally, adds its
+// public key to the account and hands out the private key for the current run
+// only. Ephemeral values are
+// never written to plan or state.
+type SshKeyPairEphemeralResource struct {
+	client *ProviderConfig
+}
+
+// SshKeyPairEphemeralModel describes the ephemeral resource data model.
+type SshKeyPairEphemeralModel struct {
+	Id            types.String `tfsdk:"id"`
+	Name          types.String `tfsdk:"name"`
+	DeleteOnClose types.Bool   `tfsdk:"delete_on_close"`
+	PublicKey     types.String `tfsdk:"public_key"`
+	PrivateKey    types.String `tfsdk:"private_key"`
+}
+
+// sshKeyPairPrivateData is what Open hands to Close through private data.
+type sshKeyPairPrivateData struct {
+	Id string `json:"id"`
+}
+
+func (r *SshKeyPairEphemeralResource) Metadata(ctx context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_ssh_key_pair"
+}
+
+func (r *SshKeyPairEphemeralResource) Schema(ctx context.Context, req ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Generates a Lambda Cloud SSH key pair whose private key is only available during the current Terraform run and never stored in state. " +
+			"Only the public key is sent to the API. Terraform opens ephemeral resources for every plan and apply, so a key generated by an earlier one is replaced with a new key pair. " +
+			"If a key with the given name was added some other way, it is fetched instead and `private_key` is null, since the API does not keep private keys.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The unique identifier (ID) of the SSH key",
+			},
+			"name": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Name of the SSH key (must be unique)",
+				Validators: []validator.String{
+					stringvalidator.LengthBetween(1, 64),
+				},
+			},
+			"delete_on_close": schema.BoolAttribute{
+				Optional: true,
+				MarkdownDescription: "Delete a generated key from the account when Terraform is done with it. " +
+					"Instances launched during the run keep the key. A kept key is replaced the next time Terraform opens the resource. Defaults to `true`",
+			},
+			"public_key": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The public key",
+			},
+			"private_key": schema.StringAttribute{
+				Computed:            true,
+				Sensitive:           true,
+				MarkdownDescription: "The OpenSSH private key, null when an existing key added outside Terraform was fetched",
+			},
+		},
+	}
+}
+
+func (r *SshKeyPairEphemeralResource) Configure(ctx context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*ProviderConfig)
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Ephemeral Resource Configure Type",
+			fmt.Sprintf("Expected *ProviderConfig, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+		return
+	}
+
+	r.client = client
+}
+
+func (r *SshKeyPairEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	var data SshKeyPairEphemeralModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.client.Policy.checkName(path.Root("name"), data.Name)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	name := data.Name.ValueString()
+
+	existing, err := r.findSshKey(ctx, name)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to list SSH keys, got error: %s", err))
+		return
+	}
+
+	if existing != nil && !isGeneratedSshKey(existing.PublicKey) {
+		data.Id = types.StringValue(existing.Id)
+		data.PublicKey = types.StringValue(existing.PublicKey)
+		data.PrivateKey = types.StringNull()
+
+		tflog.Debug(ctx, "SSH key already exists, fetched it without a private key", map[string]interface{}{"name": name})
+
+		resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+		return
+	}
+
+	// Terraform opens the resource again for every plan and apply, and the
+	// private key of a key pair generated by an earlier one is gone. Replace
+	// the key so that this run gets a private key that works.
+	if existing != nil {
+		tflog.Debug(ctx, "Replacing SSH key generated by an earlier run", map[string]interface{}{"name": name, "id": existing.Id})
+
+		if err := r.client.DeleteSshKey(ctx, existing.Id); err != nil && !isNotFound(err) {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to replace SSH key %s, got error: %s", existing.Id, err))
+			return
+		}
+	}
+
+	publicKey, privateKey, err := generateSshKeyPair()
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to generate SSH key pair, got error: %s", err))
+		return
+	}
+
+	sshKey, err := r.client.CreateSshKey(ctx, CreateSshKeyRequest{Name: name, PublicKey: &publicKey})
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create SSH key, got error: %s", err))
+		return
+	}
+
+	data.Id = types.StringValue(sshKey.Id)
+	data.PublicKey = types.StringValue(sshKey.PublicKey)
+	data.PrivateKey = types.StringValue(privateKey)
+
+	if data.DeleteOnClose.IsNull() || data.DeleteOnClose.ValueBool() {
+		privateData, err := json.Marshal(sshKeyPairPrivateData{Id: sshKey.Id})
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to record SSH key for deletion, got error: %s", err))
+			return
+		}
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, sshKeyPairPrivateKey, privateData)...)
+	}
+
+	tflog.Trace(ctx, "opened an SSH key pair ephemeral resource")
+
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+}
+
+func (r *SshKeyPairEphemeralResource) Close(ctx context.Context, req ephemeral.CloseRequest, resp *ephemeral.CloseResponse) {
+	privateData, diags := req.Private.GetKey(ctx, sshKeyPairPrivateKey)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || privateData == nil {
+		return
+	}
+
+	var key sshKeyPairPrivateData
+	if err := json.Unmarshal(privateData, &key); err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read SSH key to delete, got error: %s", err))
+		return
+	}
+
+	if err := r.client.DeleteSshKey(ctx, key.Id); err != nil && !isNotFound(err) {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete SSH key %s, got error: %s", key.Id, err))
+		return
+	}
+}
+
+// generateSshKeyPair returns a new Ed25519 key pair as an OpenSSH public key,
+// marked with sshKeyPairComment, and a PEM encoded OpenSSH private key. Only
+// the public key is sent to the API.
+func generateSshKeyPair() (string, string, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	sshPublicKey, err := ssh.NewPublicKey(publicKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	privatePem, err := ssh.MarshalPrivateKey(privateKey, sshKeyPairComment)
+	if err != nil {
+		return "", "", err
+	}
+
+	authorizedKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPublicKey)))
+	return authorizedKey + " " + sshKeyPairComment, string(pem.EncodeToMemory(privatePem)), nil
+}
+
+// isGeneratedSshKey reports whether a public key was generated by this
+// ephemeral resource, rather than added some other way.
+func isGeneratedSshKey(publicKey string) bool {
+	fields := strings.Fields(publicKey)
+	return len(fields) == 3 && fields[2] == sshKeyPairComment
+}
+
+// findSshKey returns the SSH key with the given name, or nil if there is none.
+func (r *SshKeyPairEphemeralResource) findSshKey(ctx context.Context, name string) (*SshKey, error) {
+	sshKeys, err := r.client.ListSshKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sshKey := range sshKeys {
+		if sshKey.Name == name {
+			return &sshKey, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,340 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/echoprovider"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/albertocavalcante/terraform-provider-lambda/internal/fakelambda"
+)
+
+// testAccEchoProviderFactories adds the echo provider, which copies ephemeral
+// values into the state of an echo resource so that steps can check them.
+var testAccEchoProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
+	"lambda": testAccProtoV6ProviderFactories["lambda"],
+	"echo":   echoprovider.NewProviderServer(),
+}
+
+func TestAccSshKeyPairEphemeralResource(t *testing.T) {
+	env := testAccPreCheck(t)
+	env.skipUnlessFake(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccEchoProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: env.providerConfig() + testAccSshKeyPairEphemeralResourceConfig("tf-acc-ephemeral", ""),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("echo.test", tfjsonpath.New("data").AtMapKey("name"), knownvalue.StringExact("tf-acc-ephemeral")),
+					statecheck.ExpectKnownValue("echo.test", tfjsonpath.New("data").AtMapKey("id"), knownvalue.NotNull()),
+					statecheck.ExpectKnownValue("echo.test", tfjsonpath.New("data").AtMapKey("public_key"), knownvalue.StringRegexp(regexp.MustCompile(`^ssh-ed25519 `))),
+					statecheck.ExpectKnownValue("echo.test", tfjsonpath.New("data").AtMapKey("private_key"), knownvalue.StringRegexp(regexp.MustCompile(`OPENSSH PRIVATE KEY`))),
+				},
+				// delete_on_close defaults to true
+				Check: testAccCheckSshKeyExists(env, "tf-acc-ephemeral", false),
+			},
+		},
+	})
+}
+
+func TestAccSshKeyPairEphemeralResource_keepOnClose(t *testing.T) {
+	env := testAccPreCheck(t)
+	env.skipUnlessFake(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccEchoProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: env.providerConfig() + testAccSshKeyPairEphemeralResourceConfig("tf-acc-kept", "delete_on_close = false"),
+				ConfigStateChecks: []statecheck.StateCheck{
+					// The key pair kept from planning is replaced when applying, so
+					// the private key is usable
+					statecheck.ExpectKnownValue("echo.test", tfjsonpath.New("data").AtMapKey("private_key"), knownvalue.NotNull()),
+				},
+				Check: testAccCheckSshKeyExists(env, "tf-acc-kept", true),
+			},
+		},
+	})
+}
+
+func TestAccSshKeyPairEphemeralResource_existingKey(t *testing.T) {
+	env := testAccPreCheck(t)
+	env.skipUnlessFake(t)
+
+	existing, err := env.Fake.AddSshKey("tf-acc-existing")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccEchoProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: env.providerConfig() + testAccSshKeyPairEphemeralResourceConfig("tf-acc-existing", ""),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("echo.test", tfjsonpath.New("data").AtMapKey("id"), knownvalue.StringExact(existing.Id)),
+					statecheck.ExpectKnownValue("echo.test", tfjsonpath.New("data").AtMapKey("public_key"), knownvalue.StringExact(existing.PublicKey)),
+					statecheck.ExpectKnownValue("echo.test", tfjsonpath.New("data").AtMapKey("private_key"), knownvalue.Null()),
+				},
+				// A key the ephemeral resource did not create is never deleted
+				Check: testAccCheckSshKeyExists(env, "tf-acc-existing", true),
+			},
+		},
+	})
+}
+
+func TestAccSshKeyPairEphemeralResource_namePattern(t *testing.T) {
+	env := testAccPreCheck(t)
+	env.skipUnlessFake(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccEchoProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "lambda" {
+  api_key             = %q
+  endpoint            = %q
+  allow_insecure_http = true
+  name_pattern        = "^team-"
+}
+`, env.ApiKey, env.Endpoint) + testAccSshKeyPairEphemeralResourceConfig("tf-acc-rejected", ""),
+				ExpectError: regexp.MustCompile(`Name does not match name_pattern`),
+			},
+		},
+	})
+
+	if err := testAccCheckSshKeyExists(env, "tf-acc-rejected", false)(nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// testAccCheckSshKeyExists checks whether the account has an SSH key of that
+// name once Terraform is done with the ephemeral resource.
+func testAccCheckSshKeyExists(env *testAccEnv, name string, want bool) resource.TestCheckFunc {
+	return func(*terraform.State) error {
+		var found bool
+		for _, sshKey := range env.Fake.SshKeys() {
+			if sshKey.Name == name {
+				found = true
+			}
+		}
+
+		if found != want {
+			return fmt.Errorf("expected SSH key %s to exist: %t, got %t", name, want, found)
+		}
+		return nil
+	}
+}
+
+func testAccSshKeyPairEphemeralResourceConfig(name, extra string) string {
+	return fmt.Sprintf(`
+ephemeral "lambda_ssh_key_pair" "test" {
+  name = %q
+  %s
+}
+
+provider "echo" {
+  data = ephemeral.lambda_ssh_key_pair.test
+}
+
+resource "echo" "test" {}
+`, name, extra)
+}
+
+// openTestSshKeyPair opens and closes the ephemeral resource through a
+// provider server configured against endpoint, as Terraform does for a plan or
+// an apply, and returns the result.
+func openTestSshKeyPair(t *testing.T, endpoint string, deleteOnClose *bool) map[string]tftypes.Value {
+	t.Helper()
+	ctx := context.Background()
+
+	server, err := providerserver.NewProtocol6WithError(New("test")())()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schemaResp, err := server.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	providerConfig := testDynamicValue(t, schemaResp.Provider.ValueType(), map[string]tftypes.Value{
+		"api_key":             tftypes.NewValue(tftypes.String, "test"),
+		"endpoint":            tftypes.NewValue(tftypes.String, endpoint),
+		"allow_insecure_http": tftypes.NewValue(tftypes.Bool, true),
+	})
+	configureResp, err := server.ConfigureProvider(ctx, &tfprotov6.ConfigureProviderRequest{Config: providerConfig})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTestDiagnostics(t, configureResp.Diagnostics)
+
+	resourceType := schemaResp.EphemeralResourceSchemas["lambda_ssh_key_pair"].ValueType()
+	values := map[string]tftypes.Value{"name": tftypes.NewValue(tftypes.String, "deploy")}
+	if deleteOnClose != nil {
+		values["delete_on_close"] = tftypes.NewValue(tftypes.Bool, *deleteOnClose)
+	}
+
+	openResp, err := server.OpenEphemeralResource(ctx, &tfprotov6.OpenEphemeralResourceRequest{
+		TypeName: "lambda_ssh_key_pair",
+		Config:   testDynamicValue(t, resourceType, values),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTestDiagnostics(t, openResp.Diagnostics)
+
+	closeResp, err := server.CloseEphemeralResource(ctx, &tfprotov6.CloseEphemeralResourceRequest{
+		TypeName: "lambda_ssh_key_pair",
+		Private:  openResp.Private,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTestDiagnostics(t, closeResp.Diagnostics)
+
+	result, err := openResp.Result.Unmarshal(resourceType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var attributes map[string]tftypes.Value
+	if err := result.As(&attributes); err != nil {
+		t.Fatal(err)
+	}
+	return attributes
+}
+
+// testObjectValue returns an object of type typ with the given attributes,
+// leaving the others null.
+func testObjectValue(typ tftypes.Type, values map[string]tftypes.Value) tftypes.Value {
+	attributes := map[string]tftypes.Value{}
+	for name, attributeType := range typ.(tftypes.Object).AttributeTypes {
+		attributes[name] = tftypes.NewValue(attributeType, nil)
+	}
+	for name, value := range values {
+		attributes[name] = value
+	}
+	return tftypes.NewValue(typ, attributes)
+}
+
+// testDynamicValue encodes an object of type typ with the given attributes,
+// leaving the others null.
+func testDynamicValue(t *testing.T, typ tftypes.Type, values map[string]tftypes.Value) *tfprotov6.DynamicValue {
+	t.Helper()
+
+	dynamicValue, err := tfprotov6.NewDynamicValue(typ, testObjectValue(typ, values))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &dynamicValue
+}
+
+func checkTestDiagnostics(t *testing.T, diags []*tfprotov6.Diagnostic) {
+	t.Helper()
+
+	for _, d := range diags {
+		if d.Severity == tfprotov6.DiagnosticSeverityError {
+			t.Fatalf("%s: %s", d.Summary, d.Detail)
+		}
+	}
+}
+
+func TestSshKeyPairEphemeralResourceOpenTwice(t *testing.T) {
+	isolateCredentials(t)
+
+	newFake := func(t *testing.T) (*fakelambda.Server, string) {
+		fake := fakelambda.New(fakelambda.Options{APIKey: "test"})
+		server := httptest.NewServer(fake)
+		t.Cleanup(server.Close)
+		return fake, server.URL
+	}
+
+	// stringValue returns the string held by an attribute, or "" when null.
+	stringValue := func(t *testing.T, value tftypes.Value) string {
+		var s *string
+		if err := value.As(&s); err != nil {
+			t.Fatal(err)
+		}
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+
+	for name, deleteOnClose := range map[string]bool{"delete_on_close": true, "kept on close": false} {
+		t.Run(name, func(t *testing.T) {
+			fake, endpoint := newFake(t)
+
+			// Opened for the plan, then again for the apply
+			planned := openTestSshKeyPair(t, endpoint, &deleteOnClose)
+			applied := openTestSshKeyPair(t, endpoint, &deleteOnClose)
+
+			for phase, result := range map[string]map[string]tftypes.Value{"plan": planned, "apply": applied} {
+				if !strings.Contains(stringValue(t, result["private_key"]), "OPENSSH PRIVATE KEY") {
+					t.Errorf("expected a private key at %s, got %s", phase, result["private_key"])
+				}
+			}
+
+			var keys []fakelambda.SshKey
+			for _, sshKey := range fake.SshKeys() {
+				if sshKey.Name == "deploy" {
+					keys = append(keys, sshKey)
+				}
+			}
+			if !deleteOnClose {
+				if len(keys) != 1 || keys[0].PublicKey != stringValue(t, applied["public_key"]) {
+					t.Fatalf("expected only the key pair of the apply to be kept, got %v", keys)
+				}
+
+				// The private key of the apply matches the key in the account
+				signer, err := ssh.ParsePrivateKey([]byte(stringValue(t, applied["private_key"])))
+				if err != nil {
+					t.Fatal(err)
+				}
+				publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(keys[0].PublicKey))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(signer.PublicKey().Marshal(), publicKey.Marshal()) {
+					t.Error("expected the private key to match the public key in the account")
+				}
+			} else if len(keys) != 0 {
+				t.Errorf("expected the key to be deleted on close, got %v", keys)
+			}
+		})
+	}
+
+	t.Run("a key added elsewhere is fetched each time", func(t *testing.T) {
+		fake, endpoint := newFake(t)
+		existing, err := fake.AddSshKey("deploy")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for range 2 {
+			result := openTestSshKeyPair(t, endpoint, nil)
+			if stringValue(t, result["id"]) != existing.Id || !result["private_key"].IsNull() {
+				t.Errorf("expected the existing key without a private key, got %v", result)
+			}
+		}
+		if keys := fake.SshKeys(); len(keys) != 1 || keys[0].Id != existing.Id {
+			t.Errorf("expected the existing key to be left alone, got %v", keys)
+		}
+	})
+}
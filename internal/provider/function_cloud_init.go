@@ -0,0 +1,358 @@
+package provider
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"mime/multipart"
+	"net/textproto"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"gopkg.in/yaml.v3"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ function.Function = &CloudInitFunction{}
+
+const (
+	// lambdaUserDataMaxBytes is the largest user_data the launch API accepts.
+	lambdaUserDataMaxBytes = 1 << 20
+
+	// cloudInitBoundary separates the parts of the multipart output. It is
+	// fixed so that the same input always renders the same document.
+	cloudInitBoundary = "==LAMBDA_CLOUD_INIT_BOUNDARY=="
+)
+
+// cloudInitKeys lists the supported top-level keys, and for keys whose list
+// entries are objects, the keys allowed in those objects.
+var cloudInitKeys = map[string][]string{
+	"packages":    nil,
+	"runcmd":      nil,
+	"mounts":      nil,
+	"write_files": {"path", "content", "encoding", "owner", "permissions", "append", "defer"},
+	"users":       {"name", "gecos", "groups", "primary_group", "shell", "sudo", "ssh_authorized_keys", "lock_passwd", "homedir", "uid", "system", "no_create_home"},
+}
+
+func NewCloudInitFunction() function.Function {
+	return &CloudInitFunction{}
+}
+
+// CloudInitFunction renders a #cloud-config document from structured input.
+type CloudInitFunction struct{}
+
+func (f *CloudInitFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "cloud_init"
+}
+
+func (f *CloudInitFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Render cloud-init user_data from structured input",
+		MarkdownDescription: "Renders a `#cloud-config` document for an instance's `user_data` from an object with any of the keys " +
+			"`packages`, `write_files`, `runcmd`, `users` and `mounts`. Unknown keys are rejected, as is output larger than the 1 MiB the API accepts. " +
+			"Pass `true` as the second argument to gzip the document and wrap it in a base64-encoded MIME multipart message, which cloud-init unpacks on boot.",
+		Parameters: []function.Parameter{
+			function.DynamicParameter{
+				Name:                "config",
+				MarkdownDescription: "Object with the cloud-config keys to render",
+			},
+		},
+		VariadicParameter: function.BoolParameter{
+			Name:                "gzip",
+			MarkdownDescription: "Whether to gzip the document into a multipart message. Defaults to `false`",
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *CloudInitFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var config types.Dynamic
+	var gzipFlags []bool
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &config, &gzipFlags))
+	if resp.Error != nil {
+		return
+	}
+
+	if len(gzipFlags) > 1 {
+		resp.Error = function.NewArgumentFuncError(1, "at most one gzip argument may be given")
+		return
+	}
+
+	value, err := config.UnderlyingValue().ToTerraformValue(ctx)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+
+	document, err := renderCloudConfig(value)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+
+	if len(gzipFlags) == 1 && gzipFlags[0] {
+		document, err = gzipMultipart(document)
+		if err != nil {
+			resp.Error = function.NewFuncError(fmt.Sprintf("unable to gzip cloud-config: %s", err))
+			return
+		}
+	}
+
+	if len(document) > lambdaUserDataMaxBytes {
+		resp.Error = function.NewFuncError(fmt.Sprintf(
+			"rendered user_data is %d bytes, which exceeds the Lambda Cloud limit of %d bytes", len(document), lambdaUserDataMaxBytes))
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, document))
+}
+
+// renderCloudConfig validates config and renders it as a #cloud-config document.
+func renderCloudConfig(config tftypes.Value) (string, error) {
+	decoded, err := decodeTerraformValue(config)
+	if err != nil {
+		return "", err
+	}
+
+	top, ok := decoded.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("config must be an object")
+	}
+
+	for key, value := range top {
+		objectKeys, ok := cloudInitKeys[key]
+		if !ok {
+			return "", fmt.Errorf("unsupported key %q, expected one of %s", key, strings.Join(sortedKeys(cloudInitKeys), ", "))
+		}
+		if err := validateCloudInitEntries(key, value, objectKeys); err != nil {
+			return "", err
+		}
+	}
+
+	var body bytes.Buffer
+	body.WriteString("#cloud-config\n")
+
+	encoder := yaml.NewEncoder(&body)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(top); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+
+	return body.String(), nil
+}
+
+// validateCloudInitEntries checks that value is a list and that its entries
+// have the shape cloud-init expects for key.
+func validateCloudInitEntries(key string, value interface{}, objectKeys []string) error {
+	entries, ok := value.([]interface{})
+	if !ok {
+		return fmt.Errorf("%s must be a list", key)
+	}
+
+	for i, entry := range entries {
+		switch key {
+		case "packages", "runcmd":
+			// A command or package is a string, or a list of strings such as
+			// a package name and version.
+			if _, ok := entry.(string); ok {
+				continue
+			}
+			if err := validateStringList(entry); err != nil {
+				return fmt.Errorf("%s[%d] must be a string or a list of strings", key, i)
+			}
+		case "mounts":
+			if err := validateStringList(entry); err != nil {
+				return fmt.Errorf("%s[%d] must be a list of strings", key, i)
+			}
+		case "users":
+			// "default" keeps the image's default user
+			if _, ok := entry.(string); ok {
+				continue
+			}
+			if err := validateCloudInitObject(key, i, entry, objectKeys, "name"); err != nil {
+				return err
+			}
+		case "write_files":
+			if err := validateCloudInitObject(key, i, entry, objectKeys, "path"); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateCloudInitObject(key string, i int, entry interface{}, allowed []string, required string) error {
+	object, ok := entry.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%s[%d] must be an object", key, i)
+	}
+
+	for name := range object {
+		if !containsString(allowed, name) {
+			return fmt.Errorf("%s[%d] has unsupported key %q, expected one of %s", key, i, name, strings.Join(allowed, ", "))
+		}
+	}
+
+	if _, ok := object[required]; !ok {
+		return fmt.Errorf("%s[%d] is missing required key %q", key, i, required)
+	}
+
+	return nil
+}
+
+func validateStringList(value interface{}) error {
+	list, ok := value.([]interface{})
+	if !ok {
+		return fmt.Errorf("not a list")
+	}
+	for _, item := range list {
+		if _, ok := item.(string); !ok {
+			return fmt.Errorf("not a list of strings")
+		}
+	}
+	return nil
+}
+
+// decodeTerraformValue converts a Terraform value into plain Go values that
+// can be marshalled to YAML. Null attributes are dropped so that optional
+// keys can be left unset.
+func decodeTerraformValue(value tftypes.Value) (interface{}, error) {
+	if value.IsNull() {
+		return nil, nil
+	}
+	if !value.IsKnown() {
+		return nil, fmt.Errorf("value must be known")
+	}
+
+	switch ty := value.Type(); {
+	case ty.Is(tftypes.String):
+		var s string
+		err := value.As(&s)
+		return s, err
+
+	case ty.Is(tftypes.Bool):
+		var b bool
+		err := value.As(&b)
+		return b, err
+
+	case ty.Is(tftypes.Number):
+		var n big.Float
+		if err := value.As(&n); err != nil {
+			return nil, err
+		}
+		if n.IsInt() {
+			i, _ := n.Int64()
+			return i, nil
+		}
+		f, _ := n.Float64()
+		return f, nil
+
+	case ty.Is(tftypes.List{}), ty.Is(tftypes.Set{}), ty.Is(tftypes.Tuple{}):
+		var elems []tftypes.Value
+		if err := value.As(&elems); err != nil {
+			return nil, err
+		}
+		list := make([]interface{}, 0, len(elems))
+		for _, elem := range elems {
+			decoded, err := decodeTerraformValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, decoded)
+		}
+		return list, nil
+
+	case ty.Is(tftypes.Object{}), ty.Is(tftypes.Map{}):
+		var attrs map[string]tftypes.Value
+		if err := value.As(&attrs); err != nil {
+			return nil, err
+		}
+		object := make(map[string]interface{}, len(attrs))
+		for name, attr := range attrs {
+			if attr.IsNull() {
+				continue
+			}
+			decoded, err := decodeTerraformValue(attr)
+			if err != nil {
+				return nil, err
+			}
+			object[name] = decoded
+		}
+		return object, nil
+	}
+
+	return nil, fmt.Errorf("unsupported value of type %s", value.Type())
+}
+
+// gzipMultipart gzips the document into a single-part MIME multipart message
+// with base64 transfer encoding. cloud-init decompresses gzip parts before
+// processing them.
+func gzipMultipart(document string) (string, error) {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write([]byte(document)); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	mw := multipart.NewWriter(&out)
+	if err := mw.SetBoundary(cloudInitBoundary); err != nil {
+		return "", err
+	}
+
+	fmt.Fprintf(&out, "Content-Type: multipart/mixed; boundary=\"%s\"\r\nMIME-Version: 1.0\r\n\r\n", cloudInitBoundary)
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"application/x-gzip"},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {`attachment; filename="cloud-config.gz"`},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(compressed.Bytes())
+	for len(encoded) > 76 {
+		fmt.Fprintf(part, "%s\r\n", encoded[:76])
+		encoded = encoded[76:]
+	}
+	fmt.Fprintf(part, "%s\r\n", encoded)
+
+	if err := mw.Close(); err != nil {
+		return "", err
+	}
+
+	return out.String(), nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
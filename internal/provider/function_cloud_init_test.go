@@ -0,0 +1,170 @@
+package provider
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func runCloudInit(t *testing.T, config attr.Value, gzipFlags ...bool) function.RunResponse {
+	t.Helper()
+
+	// The framework passes variadic arguments as a single tuple
+	elemTypes := make([]attr.Type, len(gzipFlags))
+	elems := make([]attr.Value, len(gzipFlags))
+	for i, flag := range gzipFlags {
+		elemTypes[i] = types.BoolType
+		elems[i] = types.BoolValue(flag)
+	}
+
+	resp := function.RunResponse{
+		Result: function.NewResultData(types.StringUnknown()),
+	}
+	NewCloudInitFunction().Run(context.Background(), function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{config, types.TupleValueMust(elemTypes, elems)}),
+	}, &resp)
+
+	return resp
+}
+
+func cloudInitConfig(attrs map[string]attr.Value) attr.Value {
+	attrTypes := make(map[string]attr.Type, len(attrs))
+	for name, value := range attrs {
+		attrTypes[name] = value.Type(context.Background())
+	}
+	return types.DynamicValue(types.ObjectValueMust(attrTypes, attrs))
+}
+
+func stringTuple(values ...string) attr.Value {
+	elemTypes := make([]attr.Type, len(values))
+	elems := make([]attr.Value, len(values))
+	for i, value := range values {
+		elemTypes[i] = types.StringType
+		elems[i] = types.StringValue(value)
+	}
+	return types.TupleValueMust(elemTypes, elems)
+}
+
+func TestCloudInitFunction(t *testing.T) {
+	writeFile := types.ObjectValueMust(
+		map[string]attr.Type{"path": types.StringType, "content": types.StringType, "owner": types.StringType},
+		map[string]attr.Value{"path": types.StringValue("/etc/motd"), "content": types.StringValue("hello\n"), "owner": types.StringNull()},
+	)
+
+	config := cloudInitConfig(map[string]attr.Value{
+		"packages":    stringTuple("htop", "nvtop"),
+		"runcmd":      stringTuple("nvidia-smi"),
+		"write_files": types.TupleValueMust([]attr.Type{writeFile.Type(context.Background())}, []attr.Value{writeFile}),
+	})
+
+	resp := runCloudInit(t, config)
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+
+	expected := `#cloud-config
+packages:
+  - htop
+  - nvtop
+runcmd:
+  - nvidia-smi
+write_files:
+  - content: |
+      hello
+    path: /etc/motd
+`
+	if got := resp.Result.Value().(types.String).ValueString(); got != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestCloudInitFunction_gzip(t *testing.T) {
+	config := cloudInitConfig(map[string]attr.Value{
+		"runcmd": stringTuple("echo hello"),
+	})
+
+	resp := runCloudInit(t, config, true)
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+
+	output := resp.Result.Value().(types.String).ValueString()
+	header, body, _ := strings.Cut(output, "\r\n\r\n")
+	_, params, err := mime.ParseMediaType(strings.TrimPrefix(strings.SplitN(header, "\r\n", 2)[0], "Content-Type: "))
+	if err != nil {
+		t.Fatalf("unable to parse content type: %s", err)
+	}
+
+	part, err := multipart.NewReader(strings.NewReader(body), params["boundary"]).NextPart()
+	if err != nil {
+		t.Fatalf("unable to read part: %s", err)
+	}
+	if got := part.Header.Get("Content-Type"); got != "application/x-gzip" {
+		t.Errorf("expected gzip part, got %q", got)
+	}
+
+	encoded, _ := io.ReadAll(part)
+	compressed, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	if err != nil {
+		t.Fatalf("unable to decode part: %s", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		t.Fatalf("unable to gunzip part: %s", err)
+	}
+	document, _ := io.ReadAll(zr)
+
+	if expected := "#cloud-config\nruncmd:\n  - echo hello\n"; string(document) != expected {
+		t.Errorf("expected %q, got %q", expected, document)
+	}
+}
+
+func TestCloudInitFunction_invalid(t *testing.T) {
+	userWithoutName := types.ObjectValueMust(
+		map[string]attr.Type{"shell": types.StringType},
+		map[string]attr.Value{"shell": types.StringValue("/bin/bash")},
+	)
+
+	testCases := map[string]struct {
+		config   attr.Value
+		expected string
+	}{
+		"unknown key": {
+			config:   cloudInitConfig(map[string]attr.Value{"runcmds": stringTuple("ls")}),
+			expected: `unsupported key "runcmds"`,
+		},
+		"not a list": {
+			config:   cloudInitConfig(map[string]attr.Value{"packages": types.StringValue("htop")}),
+			expected: "packages must be a list",
+		},
+		"missing required key": {
+			config: cloudInitConfig(map[string]attr.Value{
+				"users": types.TupleValueMust([]attr.Type{userWithoutName.Type(context.Background())}, []attr.Value{userWithoutName}),
+			}),
+			expected: `users[0] is missing required key "name"`,
+		},
+		"too large": {
+			config:   cloudInitConfig(map[string]attr.Value{"runcmd": stringTuple(strings.Repeat("x", lambdaUserDataMaxBytes))}),
+			expected: "exceeds the Lambda Cloud limit",
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			resp := runCloudInit(t, testCase.config)
+			if resp.Error == nil || !strings.Contains(resp.Error.Error(), testCase.expected) {
+				t.Errorf("expected error containing %q, got %v", testCase.expected, resp.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ function.Function = &CompareInstanceTypesFunction{}
+
+func NewCompareInstanceTypesFunction() function.Function {
+	return &CompareInstanceTypesFunction{}
+}
+
+// CompareInstanceTypesFunction orders two instance type names by GPU class.
+type CompareInstanceTypesFunction struct{}
+
+func (f *CompareInstanceTypesFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "compare_instance_types"
+}
+
+func (f *CompareInstanceTypesFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Compare two Lambda Cloud instance types by GPU class",
+		MarkdownDescription: "Returns `-1` if `a` ranks below `b`, `0` if they rank the same and `1` if `a` ranks above `b`. " +
+			"Instance types are ordered by GPU model (e.g. `a100` below `h100`), then GPU memory and form factor, then GPU count. " +
+			"Works offline from the names alone.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "a",
+				MarkdownDescription: "First instance type name",
+			},
+			function.StringParameter{
+				Name:                "b",
+				MarkdownDescription: "Second instance type name",
+			},
+		},
+		Return: function.Int64Return{},
+	}
+}
+
+func (f *CompareInstanceTypesFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var nameA, nameB string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &nameA, &nameB))
+	if resp.Error != nil {
+		return
+	}
+
+	a, err := parseInstanceTypeName(nameA)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+
+	b, err := parseInstanceTypeName(nameB)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, err.Error())
+		return
+	}
+
+	result, err := compareInstanceTypes(a, b)
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, int64(result)))
+}
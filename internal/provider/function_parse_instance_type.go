@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ function.Function = &ParseInstanceTypeFunction{}
+
+func NewParseInstanceTypeFunction() function.Function {
+	return &ParseInstanceTypeFunction{}
+}
+
+// ParseInstanceTypeFunction splits an instance type name into its parts.
+type ParseInstanceTypeFunction struct{}
+
+var parsedInstanceTypeAttrTypes = map[string]attr.Type{
+	"name":           types.StringType,
+	"gpu_count":      types.Int64Type,
+	"gpu_model":      types.StringType,
+	"form_factor":    types.StringType,
+	"gpu_memory_gib": types.Int64Type,
+	"variant":        types.StringType,
+}
+
+// ParsedInstanceTypeModel describes the object returned by parse_instance_type.
+type ParsedInstanceTypeModel struct {
+	Name         types.String `tfsdk:"name"`
+	GpuCount     types.Int64  `tfsdk:"gpu_count"`
+	GpuModel     types.String `tfsdk:"gpu_model"`
+	FormFactor   types.String `tfsdk:"form_factor"`
+	GpuMemoryGib types.Int64  `tfsdk:"gpu_memory_gib"`
+	Variant      types.String `tfsdk:"variant"`
+}
+
+func (f *ParseInstanceTypeFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "parse_instance_type"
+}
+
+func (f *ParseInstanceTypeFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary: "Parse a Lambda Cloud instance type name",
+		MarkdownDescription: "Splits an instance type name such as `gpu_8x_h100_sxm5` into its GPU count, GPU model and qualifiers, without calling the API. " +
+			"`form_factor`, `gpu_memory_gib` and `variant` are null when the name does not carry them.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "name",
+				MarkdownDescription: "Instance type name, e.g. `gpu_8x_h100_sxm5`",
+			},
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: parsedInstanceTypeAttrTypes,
+		},
+	}
+}
+
+func (f *ParseInstanceTypeFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var name string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &name))
+	if resp.Error != nil {
+		return
+	}
+
+	parsed, err := parseInstanceTypeName(name)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+
+	result := ParsedInstanceTypeModel{
+		Name:         types.StringValue(parsed.Name),
+		GpuCount:     types.Int64Value(parsed.GpuCount),
+		GpuModel:     types.StringValue(parsed.GpuModel),
+		FormFactor:   types.StringNull(),
+		GpuMemoryGib: types.Int64Null(),
+		Variant:      types.StringNull(),
+	}
+	if parsed.FormFactor != "" {
+		result.FormFactor = types.StringValue(parsed.FormFactor)
+	}
+	if parsed.GpuMemoryGib != 0 {
+		result.GpuMemoryGib = types.Int64Value(parsed.GpuMemoryGib)
+	}
+	if parsed.Variant != "" {
+		result.Variant = types.StringValue(parsed.Variant)
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, &result))
+}
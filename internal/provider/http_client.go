@@ -0,0 +1,293 @@
+package provider
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+const (
+	defaultEndpoint       = "https://cloud.lambda.ai"
+	defaultRequestTimeout = time.Minute
+	defaultMaxRetries     = 3
+)
+
+// Environment variables for the provider's HTTP settings. Values set in the
+// provider block take precedence.
+const (
+	envEndpoint           = "LAMBDA_CLOUD_ENDPOINT"
+	envRequestTimeout     = "LAMBDA_CLOUD_REQUEST_TIMEOUT"
+	envMaxRetries         = "LAMBDA_CLOUD_MAX_RETRIES"
+	envHTTPProxy          = "LAMBDA_CLOUD_HTTP_PROXY"
+	envCACertFile         = "LAMBDA_CLOUD_CA_CERT_FILE"
+	envCACertPEM          = "LAMBDA_CLOUD_CA_CERT_PEM"
+	envInsecureSkipVerify = "LAMBDA_CLOUD_INSECURE_SKIP_VERIFY"
+	envAllowInsecureHTTP  = "LAMBDA_CLOUD_ALLOW_INSECURE_HTTP"
+	envUserAgentSuffix    = "LAMBDA_CLOUD_USER_AGENT_SUFFIX"
+
+	envRequestsPerSecond     = "LAMBDA_CLOUD_REQUESTS_PER_SECOND"
+	envMaxConcurrentRequests = "LAMBDA_CLOUD_MAX_CONCURRENT_REQUESTS"
+)
+
+// httpSettings are the resolved HTTP settings of the provider.
+type httpSettings struct {
+	Endpoint           string
+	RequestTimeout     time.Duration
+	MaxRetries         int
+	HTTPProxy          *url.URL
+	CACertPEM          []byte
+	InsecureSkipVerify bool
+	UserAgentSuffix    string
+
+	RequestsPerSecond     float64
+	MaxConcurrentRequests int
+}
+
+// httpSettings resolves the HTTP settings from the provider block and the
+// environment, and validates them.
+func (m *LambdaProviderModel) httpSettings() (httpSettings, diag.Diagnostics) {
+	var settings httpSettings
+	var diags diag.Diagnostics
+
+	// Endpoint
+	endpoint := stringSetting(m.Endpoint, envEndpoint, defaultEndpoint)
+	allowInsecureHTTP, err := boolSetting(m.AllowInsecureHTTP, envAllowInsecureHTTP)
+	if err != nil {
+		diags.AddError("Invalid "+envAllowInsecureHTTP, err.Error())
+	}
+
+	settings.Endpoint, err = validateEndpoint(endpoint, allowInsecureHTTP)
+	if err != nil {
+		diags.AddAttributeError(path.Root("endpoint"), "Invalid endpoint", err.Error())
+	}
+
+	// Request timeout
+	settings.RequestTimeout = defaultRequestTimeout
+	if timeout := stringSetting(m.RequestTimeout, envRequestTimeout, ""); timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err == nil && d <= 0 {
+			err = fmt.Errorf("duration must be greater than zero")
+		}
+		if err != nil {
+			diags.AddAttributeError(path.Root("request_timeout"), "Invalid request timeout",
+				fmt.Sprintf("request_timeout must be a positive duration such as \"30s\", got %q: %s", timeout, err))
+		}
+		settings.RequestTimeout = d
+	}
+
+	// Retries
+	settings.MaxRetries = defaultMaxRetries
+	if !m.MaxRetries.IsNull() {
+		settings.MaxRetries = int(m.MaxRetries.ValueInt64())
+	} else if value := os.Getenv(envMaxRetries); value != "" {
+		retries, err := strconv.Atoi(value)
+		if err != nil || retries < 0 {
+			diags.AddError("Invalid "+envMaxRetries, fmt.Sprintf("%s must be a non-negative integer, got %q", envMaxRetries, value))
+		}
+		settings.MaxRetries = retries
+	}
+
+	// Proxy
+	if proxy := stringSetting(m.HTTPProxy, envHTTPProxy, ""); proxy != "" {
+		settings.HTTPProxy, err = url.Parse(proxy)
+		if err == nil && (settings.HTTPProxy.Host == "" || !containsString([]string{"http", "https", "socks5"}, settings.HTTPProxy.Scheme)) {
+			err = fmt.Errorf("expected a URL such as http://proxy.example.com:3128")
+		}
+		if err != nil {
+			diags.AddAttributeError(path.Root("http_proxy"), "Invalid HTTP proxy", fmt.Sprintf("Unable to use proxy %q: %s", proxy, err))
+		}
+	}
+
+	// Certificates
+	caCertFile := stringSetting(m.CACertFile, envCACertFile, "")
+	caCertPEM := stringSetting(m.CACertPEM, envCACertPEM, "")
+	switch {
+	case caCertFile != "" && caCertPEM != "":
+		diags.AddError("Conflicting CA certificates", "Only one of ca_cert_file and ca_cert_pem may be set.")
+	case caCertFile != "":
+		pem, err := os.ReadFile(caCertFile)
+		if err != nil {
+			diags.AddAttributeError(path.Root("ca_cert_file"), "Unable to read CA certificate", err.Error())
+		}
+		settings.CACertPEM = pem
+	case caCertPEM != "":
+		settings.CACertPEM = []byte(caCertPEM)
+	}
+
+	settings.InsecureSkipVerify, err = boolSetting(m.InsecureSkipVerify, envInsecureSkipVerify)
+	if err != nil {
+		diags.AddError("Invalid "+envInsecureSkipVerify, err.Error())
+	}
+	if settings.InsecureSkipVerify && isDefaultEndpoint(settings.Endpoint) {
+		diags.AddAttributeError(
+			path.Root("insecure_skip_verify"),
+			"Certificate verification required",
+			"insecure_skip_verify may only be used with a custom endpoint, never with "+defaultEndpoint+".",
+		)
+	}
+
+	settings.UserAgentSuffix = stringSetting(m.UserAgentSuffix, envUserAgentSuffix, "")
+
+	// Rate limits
+	settings.RequestsPerSecond = defaultRequestsPerSecond
+	if !m.RequestsPerSecond.IsNull() {
+		settings.RequestsPerSecond = m.RequestsPerSecond.ValueFloat64()
+	} else if value := os.Getenv(envRequestsPerSecond); value != "" {
+		rps, err := strconv.ParseFloat(value, 64)
+		if err != nil || rps <= 0 {
+			diags.AddError("Invalid "+envRequestsPerSecond, fmt.Sprintf("%s must be a positive number, got %q", envRequestsPerSecond, value))
+		}
+		settings.RequestsPerSecond = rps
+	}
+
+	settings.MaxConcurrentRequests = defaultMaxConcurrentRequests
+	if !m.MaxConcurrentRequests.IsNull() {
+		settings.MaxConcurrentRequests = int(m.MaxConcurrentRequests.ValueInt64())
+	} else if value := os.Getenv(envMaxConcurrentRequests); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			diags.AddError("Invalid "+envMaxConcurrentRequests, fmt.Sprintf("%s must be a positive integer, got %q", envMaxConcurrentRequests, value))
+		}
+		settings.MaxConcurrentRequests = n
+	}
+
+	return settings, diags
+}
+
+// validateEndpoint checks that endpoint is an absolute HTTP(S) URL and returns
+// it without a trailing slash. Plain HTTP would send the API key in the clear,
+// so it must be allowed explicitly.
+func validateEndpoint(endpoint string, allowInsecureHTTP bool) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("%q is not a valid URL: %s", endpoint, err)
+	}
+
+	switch {
+	case u.Scheme != "http" && u.Scheme != "https":
+		return "", fmt.Errorf("%q must be an http or https URL", endpoint)
+	case u.Host == "":
+		return "", fmt.Errorf("%q has no host", endpoint)
+	case u.User != nil:
+		return "", fmt.Errorf("%q must not contain credentials", endpoint)
+	case u.RawQuery != "" || u.Fragment != "":
+		return "", fmt.Errorf("%q must not have a query or fragment", endpoint)
+	case u.Scheme == "http" && !allowInsecureHTTP:
+		return "", fmt.Errorf("%q uses plain HTTP, which sends the API key unencrypted. "+
+			"Use https, or set allow_insecure_http to true for a local or test endpoint", endpoint)
+	}
+
+	return strings.TrimSuffix(endpoint, "/"), nil
+}
+
+// isDefaultEndpoint reports whether endpoint points at the host of the public
+// Lambda Cloud API, however it is spelled.
+func isDefaultEndpoint(endpoint string) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false
+	}
+	defaultURL, _ := url.Parse(defaultEndpoint)
+	return strings.EqualFold(u.Hostname(), defaultURL.Hostname())
+}
+
+// newHTTPClient builds the client for API calls. Requests pass through, from
+// the outside in: the User-Agent, retries, rate limiting, logging and, when
+// enabled, the record/replay cassette.
+func newHTTPClient(settings httpSettings, userAgent string, cassette func(http.RoundTripper) (http.RoundTripper, error)) (*http.Client, error) {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+
+	if settings.HTTPProxy != nil {
+		base.Proxy = http.ProxyURL(settings.HTTPProxy)
+	}
+
+	if settings.CACertPEM != nil || settings.InsecureSkipVerify {
+		tlsConfig := &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: settings.InsecureSkipVerify, //nolint:gosec // Only allowed for custom endpoints
+		}
+
+		if settings.CACertPEM != nil {
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(settings.CACertPEM) {
+				return nil, fmt.Errorf("no PEM encoded certificates found in the CA certificate")
+			}
+			tlsConfig.RootCAs = pool
+		}
+
+		base.TLSClientConfig = tlsConfig
+	}
+
+	var transport http.RoundTripper = base
+	if cassette != nil {
+		var err error
+		if transport, err = cassette(transport); err != nil {
+			return nil, err
+		}
+	}
+
+	transport = newLoggingTransport(transport)
+	if settings.RequestsPerSecond > 0 && settings.MaxConcurrentRequests > 0 {
+		transport = newRateLimitTransport(transport, settings.RequestsPerSecond, settings.MaxConcurrentRequests)
+	}
+	transport = newRetryTransport(transport, settings.MaxRetries, settings.RequestTimeout)
+	transport = &userAgentTransport{next: transport, userAgent: userAgent}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// userAgentTransport sets the User-Agent of every request.
+type userAgentTransport struct {
+	next      http.RoundTripper
+	userAgent string
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.next.RoundTrip(req)
+}
+
+// stringSetting returns the configured value, falling back to the environment
+// variable and then to def.
+func stringSetting(value types.String, env, def string) string {
+	if !value.IsNull() && !value.IsUnknown() {
+		return value.ValueString()
+	}
+	if fromEnv := os.Getenv(env); fromEnv != "" {
+		return fromEnv
+	}
+	return def
+}
+
+// boolSetting returns the configured value, falling back to the environment
+// variable and then to false.
+func boolSetting(value types.Bool, env string) (bool, error) {
+	if !value.IsNull() && !value.IsUnknown() {
+		return value.ValueBool(), nil
+	}
+
+	fromEnv := os.Getenv(env)
+	if fromEnv == "" {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(fromEnv)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false, got %q", env, fromEnv)
+	}
+	return b, nil
+}
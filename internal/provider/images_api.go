@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Image represents a machine image from the images API
+type Image struct {
+	Id           string    `json:"id"`
+	CreatedTime  time.Time `json:"created_time"`
+	UpdatedTime  time.Time `json:"updated_time"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	Family       string    `json:"family"`
+	Version      string    `json:"version"`
+	Architecture string    `json:"architecture"`
+	Region       Region    `json:"region"`
+}
+
+// ImagesResponse represents the response from the list images API
+type ImagesResponse struct {
+	Data []Image `json:"data"`
+}
+
+// ListImages lists the machine images available to launch instances from
+func (c *ProviderConfig) ListImages(ctx context.Context) ([]Image, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET",
+		fmt.Sprintf("%s/api/v1/images", c.Endpoint), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c.AddAuthHeader(httpReq)
+
+	httpResp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := httpResp.Body.Close(); err != nil {
+			tflog.Warn(ctx, "Failed to close response body", map[string]interface{}{"error": err})
+		}
+	}()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, newAPIError("list images", httpResp)
+	}
+
+	var imagesResp ImagesResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&imagesResp); err != nil {
+		return nil, err
+	}
+
+	return imagesResp.Data, nil
+}
@@ -0,0 +1,265 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// LaunchRequest represents the request body for launching instances
+type LaunchRequest struct {
+	RegionName       string   `json:"region_name"`
+	InstanceTypeName string   `json:"instance_type_name"`
+	SshKeyNames      []string `json:"ssh_key_names"`
+	Name             *string  `json:"name,omitempty"`
+	FileSystemNames  []string `json:"file_system_names,omitempty"`
+	UserData         string   `json:"user_data,omitempty"`
+	Quantity         int      `json:"quantity,omitempty"`
+}
+
+// LaunchResponse represents the response from the launch API
+type LaunchResponse struct {
+	Data struct {
+		InstanceIds []string `json:"instance_ids"`
+	} `json:"data"`
+}
+
+// Instance represents an instance from the read API
+type Instance struct {
+	Id           string              `json:"id"`
+	Name         string              `json:"name"`
+	Ip           string              `json:"ip"`
+	PrivateIp    string              `json:"private_ip"`
+	Hostname     string              `json:"hostname"`
+	Status       string              `json:"status"`
+	Region       Region              `json:"region"`
+	InstanceType InstanceTypeDetails `json:"instance_type"`
+}
+
+// Region represents a Lambda Cloud region
+type Region struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// InstanceResponse represents the response from the read API
+type InstanceResponse struct {
+	Data Instance `json:"data"`
+}
+
+// InstancesResponse represents the response from the list API
+type InstancesResponse struct {
+	Data []Instance `json:"data"`
+}
+
+// LaunchInstances launches instances and returns the IDs of every instance
+// the API reports as launched. It never returns an empty slice without an
+// error.
+func (c *ProviderConfig) LaunchInstances(ctx context.Context, launchReq LaunchRequest) ([]string, error) {
+	jsonData, err := json.Marshal(launchReq)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST",
+		fmt.Sprintf("%s/api/v1/instance-operations/launch", c.Endpoint),
+		strings.NewReader(string(jsonData)))
+	if err != nil {
+		return nil, err
+	}
+
+	c.AddAuthHeader(httpReq)
+
+	httpResp, err := c.HTTPClient.Do(httpReq)
+
+	// Instances may have changed even if the call failed
+	c.instances.invalidate()
+
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := httpResp.Body.Close(); err != nil {
+			tflog.Warn(ctx, "Failed to close response body", map[string]interface{}{"error": err})
+		}
+	}()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, newAPIError("launch", httpResp)
+	}
+
+	var launchResp LaunchResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&launchResp); err != nil {
+		return nil, err
+	}
+
+	if len(launchResp.Data.InstanceIds) == 0 {
+		return nil, fmt.Errorf("no instance IDs returned from launch API")
+	}
+
+	return launchResp.Data.InstanceIds, nil
+}
+
+// GetInstance fetches a single instance by ID
+func (c *ProviderConfig) GetInstance(ctx context.Context, instanceId string) (*Instance, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET",
+		fmt.Sprintf("%s/api/v1/instances/%s", c.Endpoint, instanceId),
+		nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c.AddAuthHeader(httpReq)
+
+	httpResp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := httpResp.Body.Close(); err != nil {
+			tflog.Warn(ctx, "Failed to close response body", map[string]interface{}{"error": err})
+		}
+	}()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, newAPIError("read", httpResp)
+	}
+
+	var instanceResp InstanceResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&instanceResp); err != nil {
+		return nil, err
+	}
+
+	return &instanceResp.Data, nil
+}
+
+// RenameInstance sets the name of an instance
+func (c *ProviderConfig) RenameInstance(ctx context.Context, instanceId string, name string) error {
+	jsonData, err := json.Marshal(map[string]string{"name": name})
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST",
+		fmt.Sprintf("%s/api/v1/instances/%s", c.Endpoint, instanceId),
+		strings.NewReader(string(jsonData)))
+	if err != nil {
+		return err
+	}
+
+	c.AddAuthHeader(httpReq)
+
+	httpResp, err := c.HTTPClient.Do(httpReq)
+
+	// The listing may hold the old name
+	c.instances.invalidate()
+
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := httpResp.Body.Close(); err != nil {
+			tflog.Warn(ctx, "Failed to close response body", map[string]interface{}{"error": err})
+		}
+	}()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return newAPIError("rename", httpResp)
+	}
+
+	return nil
+}
+
+// ListInstances lists the running instances of the account
+func (c *ProviderConfig) ListInstances(ctx context.Context) ([]Instance, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET",
+		fmt.Sprintf("%s/api/v1/instances", c.Endpoint),
+		nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c.AddAuthHeader(httpReq)
+
+	httpResp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := httpResp.Body.Close(); err != nil {
+			tflog.Warn(ctx, "Failed to close response body", map[string]interface{}{"error": err})
+		}
+	}()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, newAPIError("list instances", httpResp)
+	}
+
+	var instancesResp InstancesResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&instancesResp); err != nil {
+		return nil, err
+	}
+
+	return instancesResp.Data, nil
+}
+
+// TerminateInstances terminates the given instances in a single call
+func (c *ProviderConfig) TerminateInstances(ctx context.Context, instanceIds []string) error {
+	return c.instanceOperation(ctx, "terminate", instanceIds)
+}
+
+// RestartInstances restarts the given instances in a single call
+func (c *ProviderConfig) RestartInstances(ctx context.Context, instanceIds []string) error {
+	return c.instanceOperation(ctx, "restart", instanceIds)
+}
+
+// ColdRebootInstances power cycles the given instances in a single call
+func (c *ProviderConfig) ColdRebootInstances(ctx context.Context, instanceIds []string) error {
+	return c.instanceOperation(ctx, "cold-reboot", instanceIds)
+}
+
+// instanceOperation calls one of the /api/v1/instance-operations endpoints
+// that take a list of instance IDs.
+func (c *ProviderConfig) instanceOperation(ctx context.Context, operation string, instanceIds []string) error {
+	operationReq := map[string][]string{
+		"instance_ids": instanceIds,
+	}
+
+	jsonData, err := json.Marshal(operationReq)
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST",
+		fmt.Sprintf("%s/api/v1/instance-operations/%s", c.Endpoint, operation),
+		strings.NewReader(string(jsonData)))
+	if err != nil {
+		return err
+	}
+
+	c.AddAuthHeader(httpReq)
+
+	httpResp, err := c.HTTPClient.Do(httpReq)
+
+	// Instances may have changed even if the call failed
+	c.instances.invalidate()
+
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := httpResp.Body.Close(); err != nil {
+			tflog.Warn(ctx, "Failed to close response body", map[string]interface{}{"error": err})
+		}
+	}()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return newAPIError(operation, httpResp)
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/sync/singleflight"
+)
+
+// defaultInstanceSnapshotTTL is how long a listing of every instance serves
+// reads. It covers the refresh at the start of a run without serving data
+// much older than the run.
+const defaultInstanceSnapshotTTL = 30 * time.Second
+
+// instanceSnapshot serves instance reads from a single listing of every
+// instance, so that refreshing hundreds of instances takes one API call rather
+// than one each. Concurrent reads that find no fresh listing share one list
+// call.
+type instanceSnapshot struct {
+	ttl  time.Duration
+	now  func() time.Time
+	list func(context.Context) ([]Instance, error)
+
+	group singleflight.Group
+
+	mu        sync.Mutex
+	instances map[string]Instance
+	fetchedAt time.Time
+	// generation counts invalidations, so that a listing started before
+	// one is not kept.
+	generation int
+}
+
+func newInstanceSnapshot(ttl time.Duration, list func(context.Context) ([]Instance, error)) *instanceSnapshot {
+	return &instanceSnapshot{ttl: ttl, now: time.Now, list: list}
+}
+
+// lookup returns the instance from a fresh listing, fetching one if needed.
+// ok is false when the listing does not have the instance.
+func (s *instanceSnapshot) lookup(ctx context.Context, instanceId string) (instance Instance, ok bool, err error) {
+	if instances := s.fresh(); instances != nil {
+		instance, ok = instances[instanceId]
+		return instance, ok, nil
+	}
+
+	result, err, _ := s.group.Do("instances", func() (interface{}, error) {
+		// Another call may have refreshed the listing meanwhile
+		if instances := s.fresh(); instances != nil {
+			return instances, nil
+		}
+
+		s.mu.Lock()
+		generation := s.generation
+		s.mu.Unlock()
+
+		fetchedAt := s.now()
+		listed, err := s.list(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		instances := make(map[string]Instance, len(listed))
+		for _, instance := range listed {
+			instances[instance.Id] = instance
+		}
+
+		s.mu.Lock()
+		if s.generation == generation {
+			s.instances, s.fetchedAt = instances, fetchedAt
+		}
+		s.mu.Unlock()
+
+		tflog.Debug(ctx, "Listed instances to serve reads", map[string]interface{}{"count": len(instances)})
+
+		return instances, nil
+	})
+	if err != nil {
+		return Instance{}, false, err
+	}
+
+	instance, ok = result.(map[string]Instance)[instanceId]
+	return instance, ok, nil
+}
+
+// fresh returns the current listing, or nil if there is none or it is older
+// than the TTL.
+func (s *instanceSnapshot) fresh() map[string]Instance {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.instances == nil || s.now().Sub(s.fetchedAt) >= s.ttl {
+		return nil
+	}
+	return s.instances
+}
+
+// invalidate drops the listing, for example after instances were launched or
+// terminated. It does nothing on a nil snapshot.
+func (s *instanceSnapshot) invalidate() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.instances = nil
+	s.generation++
+}
+
+// readInstance returns an instance, from the snapshot when the provider keeps
+// one. Instances missing from the snapshot, and any failure to list, fall back
+// to fetching the instance by ID.
+func (c *ProviderConfig) readInstance(ctx context.Context, instanceId string) (*Instance, error) {
+	if c.instances == nil {
+		return c.GetInstance(ctx, instanceId)
+	}
+
+	instance, ok, err := c.instances.lookup(ctx, instanceId)
+	if err != nil {
+		tflog.Warn(ctx, "Unable to list instances, reading the instance on its own", map[string]interface{}{"error": err.Error()})
+	}
+	if ok {
+		return &instance, nil
+	}
+
+	return c.GetInstance(ctx, instanceId)
+}
@@ -0,0 +1,150 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/albertocavalcante/terraform-provider-lambda/internal/fakelambda"
+)
+
+// countInstanceReads returns how many instance list and get calls fake served.
+func countInstanceReads(fake *fakelambda.Server) (lists, gets int) {
+	for _, req := range fake.Requests() {
+		switch {
+		case req.Method != http.MethodGet:
+		case req.Path == "/api/v1/instances":
+			lists++
+		case strings.HasPrefix(req.Path, "/api/v1/instances/"):
+			gets++
+		}
+	}
+	return lists, gets
+}
+
+func TestInstanceSnapshot(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T, instances int) (*ProviderConfig, *fakelambda.Server, []string) {
+		t.Helper()
+
+		fake := fakelambda.New(fakelambda.Options{})
+		if _, err := fake.AddSshKey("snapshot"); err != nil {
+			t.Fatal(err)
+		}
+		server := httptest.NewServer(fake)
+		t.Cleanup(server.Close)
+
+		client := &ProviderConfig{ApiKey: "test", Endpoint: server.URL, HTTPClient: &http.Client{}}
+		ids, err := client.LaunchInstances(ctx, LaunchRequest{
+			RegionName:       "us-east-1",
+			InstanceTypeName: "gpu_1x_a10",
+			SshKeyNames:      []string{"snapshot"},
+			Quantity:         instances,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		client.instances = newInstanceSnapshot(time.Minute, client.ListInstances)
+		return client, fake, ids
+	}
+
+	t.Run("concurrent reads share one list call", func(t *testing.T) {
+		client, fake, ids := setup(t, 8)
+
+		var wg sync.WaitGroup
+		for _, id := range ids {
+			wg.Add(1)
+			go func(id string) {
+				defer wg.Done()
+				instance, err := client.readInstance(ctx, id)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if instance.Id != id {
+					t.Errorf("expected instance %s, got %s", id, instance.Id)
+				}
+			}(id)
+		}
+		wg.Wait()
+
+		if lists, gets := countInstanceReads(fake); lists != 1 || gets != 0 {
+			t.Errorf("expected 1 list call and no get calls, got %d and %d", lists, gets)
+		}
+	})
+
+	t.Run("misses fall back to reading by ID", func(t *testing.T) {
+		client, fake, ids := setup(t, 1)
+
+		if _, err := client.readInstance(ctx, ids[0]); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.readInstance(ctx, "missing"); !isNotFound(err) {
+			t.Fatalf("expected a not found error, got %v", err)
+		}
+
+		if lists, gets := countInstanceReads(fake); lists != 1 || gets != 1 {
+			t.Errorf("expected 1 list call and 1 get call, got %d and %d", lists, gets)
+		}
+	})
+
+	t.Run("list failures fall back to reading by ID", func(t *testing.T) {
+		client, fake, ids := setup(t, 1)
+		fake.InjectFault(fakelambda.Fault{
+			Method:     http.MethodGet,
+			PathPrefix: "/api/v1/instances",
+			StatusCode: http.StatusInternalServerError,
+			Code:       fakelambda.ErrorCodeInternal,
+			Times:      1,
+		})
+
+		if _, err := client.readInstance(ctx, ids[0]); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("the snapshot expires", func(t *testing.T) {
+		client, fake, ids := setup(t, 1)
+		now := time.Now()
+		client.instances.now = func() time.Time { return now }
+
+		for i := 0; i < 2; i++ {
+			if _, err := client.readInstance(ctx, ids[0]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		now = now.Add(time.Minute)
+		if _, err := client.readInstance(ctx, ids[0]); err != nil {
+			t.Fatal(err)
+		}
+
+		if lists, _ := countInstanceReads(fake); lists != 2 {
+			t.Errorf("expected 2 list calls, got %d", lists)
+		}
+	})
+
+	t.Run("instance operations invalidate the snapshot", func(t *testing.T) {
+		client, fake, ids := setup(t, 1)
+
+		if _, err := client.readInstance(ctx, ids[0]); err != nil {
+			t.Fatal(err)
+		}
+		if err := client.TerminateInstances(ctx, ids); err != nil {
+			t.Fatal(err)
+		}
+		// The API stand-in removes terminated instances at once
+		if _, err := client.readInstance(ctx, ids[0]); !isNotFound(err) {
+			t.Fatalf("expected the terminated instance to be gone, got %v", err)
+		}
+
+		if lists, _ := countInstanceReads(fake); lists != 2 {
+			t.Errorf("expected 2 list calls, got %d", lists)
+		}
+	})
+}
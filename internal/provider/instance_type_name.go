@@ -0,0 +1,119 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// instanceTypeNamePattern matches Lambda Cloud instance type names such as
+// "gpu_1x_a10" or "gpu_8x_a100_80gb_sxm4": the GPU count, then the GPU model,
+// then any qualifiers.
+var instanceTypeNamePattern = regexp.MustCompile(`^gpu_([1-9][0-9]*)x_([a-z0-9]+)((?:_[a-z0-9]+)*)$`)
+
+var (
+	formFactorPattern = regexp.MustCompile(`^(sxm[0-9]+|pcie)$`)
+	gpuMemoryPattern  = regexp.MustCompile(`^([0-9]+)gb$`)
+)
+
+// gpuClassRanks orders the GPU models offered by Lambda Cloud from the oldest
+// and least capable to the newest. compareInstanceTypes relies on it, so new
+// models must be added here.
+var gpuClassRanks = map[string]int{
+	"rtx6000": 1,
+	"v100":    2,
+	"a10":     3,
+	"a6000":   4,
+	"a100":    5,
+	"h100":    6,
+	"gh200":   7,
+	"b200":    8,
+}
+
+// InstanceTypeName is the parsed form of an instance type name.
+type InstanceTypeName struct {
+	Name       string
+	GpuCount   int64
+	GpuModel   string
+	FormFactor string
+	// GpuMemoryGib is only known when the name carries it, as in "80gb".
+	GpuMemoryGib int64
+	// Variant holds any remaining qualifiers, such as the "n" of "gpu_8x_v100_n".
+	Variant string
+}
+
+// parseInstanceTypeName parses an instance type name without calling the API.
+func parseInstanceTypeName(name string) (InstanceTypeName, error) {
+	match := instanceTypeNamePattern.FindStringSubmatch(name)
+	if match == nil {
+		return InstanceTypeName{}, fmt.Errorf("%q is not a Lambda Cloud instance type name, expected a name like \"gpu_8x_h100_sxm5\"", name)
+	}
+
+	gpuCount, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil {
+		return InstanceTypeName{}, fmt.Errorf("%q has an invalid GPU count: %w", name, err)
+	}
+
+	parsed := InstanceTypeName{
+		Name:     name,
+		GpuCount: gpuCount,
+		GpuModel: match[2],
+	}
+
+	var variant []string
+	for _, qualifier := range strings.Split(strings.TrimPrefix(match[3], "_"), "_") {
+		switch {
+		case qualifier == "":
+		case formFactorPattern.MatchString(qualifier):
+			parsed.FormFactor = qualifier
+		case gpuMemoryPattern.MatchString(qualifier):
+			parsed.GpuMemoryGib, _ = strconv.ParseInt(gpuMemoryPattern.FindStringSubmatch(qualifier)[1], 10, 64)
+		default:
+			variant = append(variant, qualifier)
+		}
+	}
+	parsed.Variant = strings.Join(variant, "_")
+
+	return parsed, nil
+}
+
+// compareInstanceTypes orders two instance types by GPU class: first the GPU
+// model, then the GPU memory and form factor within a model, then the number
+// of GPUs. It returns -1, 0 or 1.
+func compareInstanceTypes(a, b InstanceTypeName) (int, error) {
+	rankA, ok := gpuClassRanks[a.GpuModel]
+	if !ok {
+		return 0, fmt.Errorf("unknown GPU model %q in %q, a newer provider version may be required", a.GpuModel, a.Name)
+	}
+	rankB, ok := gpuClassRanks[b.GpuModel]
+	if !ok {
+		return 0, fmt.Errorf("unknown GPU model %q in %q, a newer provider version may be required", b.GpuModel, b.Name)
+	}
+
+	for _, pair := range [][2]int64{
+		{int64(rankA), int64(rankB)},
+		{a.GpuMemoryGib, b.GpuMemoryGib},
+		{formFactorRank(a.FormFactor), formFactorRank(b.FormFactor)},
+		{a.GpuCount, b.GpuCount},
+	} {
+		switch {
+		case pair[0] < pair[1]:
+			return -1, nil
+		case pair[0] > pair[1]:
+			return 1, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// formFactorRank ranks SXM boards, with their faster interconnect, above PCIe
+// cards, and newer SXM generations above older ones.
+func formFactorRank(formFactor string) int64 {
+	if generation, ok := strings.CutPrefix(formFactor, "sxm"); ok {
+		n, _ := strconv.ParseInt(generation, 10, 64)
+		return 1 + n
+	}
+	return 0
+}
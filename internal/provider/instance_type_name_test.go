@@ -0,0 +1,174 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// lambdaInstanceTypeNames is the set of instance type names currently offered
+// by Lambda Cloud, with how each one is expected to parse.
+var lambdaInstanceTypeNames = map[string]InstanceTypeName{
+	"gpu_1x_rtx6000":        {GpuCount: 1, GpuModel: "rtx6000"},
+	"gpu_8x_v100":           {GpuCount: 8, GpuModel: "v100"},
+	"gpu_8x_v100_n":         {GpuCount: 8, GpuModel: "v100", Variant: "n"},
+	"gpu_1x_a10":            {GpuCount: 1, GpuModel: "a10"},
+	"gpu_1x_a6000":          {GpuCount: 1, GpuModel: "a6000"},
+	"gpu_2x_a6000":          {GpuCount: 2, GpuModel: "a6000"},
+	"gpu_4x_a6000":          {GpuCount: 4, GpuModel: "a6000"},
+	"gpu_1x_a100":           {GpuCount: 1, GpuModel: "a100"},
+	"gpu_2x_a100":           {GpuCount: 2, GpuModel: "a100"},
+	"gpu_4x_a100":           {GpuCount: 4, GpuModel: "a100"},
+	"gpu_8x_a100":           {GpuCount: 8, GpuModel: "a100"},
+	"gpu_1x_a100_sxm4":      {GpuCount: 1, GpuModel: "a100", FormFactor: "sxm4"},
+	"gpu_2x_a100_pcie":      {GpuCount: 2, GpuModel: "a100", FormFactor: "pcie"},
+	"gpu_8x_a100_80gb_sxm4": {GpuCount: 8, GpuModel: "a100", FormFactor: "sxm4", GpuMemoryGib: 80},
+	"gpu_1x_h100_pcie":      {GpuCount: 1, GpuModel: "h100", FormFactor: "pcie"},
+	"gpu_1x_h100_sxm5":      {GpuCount: 1, GpuModel: "h100", FormFactor: "sxm5"},
+	"gpu_2x_h100_sxm5":      {GpuCount: 2, GpuModel: "h100", FormFactor: "sxm5"},
+	"gpu_4x_h100_sxm5":      {GpuCount: 4, GpuModel: "h100", FormFactor: "sxm5"},
+	"gpu_8x_h100_sxm5":      {GpuCount: 8, GpuModel: "h100", FormFactor: "sxm5"},
+	"gpu_1x_gh200":          {GpuCount: 1, GpuModel: "gh200"},
+	"gpu_1x_b200_sxm6":      {GpuCount: 1, GpuModel: "b200", FormFactor: "sxm6"},
+	"gpu_2x_b200_sxm6":      {GpuCount: 2, GpuModel: "b200", FormFactor: "sxm6"},
+	"gpu_4x_b200_sxm6":      {GpuCount: 4, GpuModel: "b200", FormFactor: "sxm6"},
+	"gpu_8x_b200_sxm6":      {GpuCount: 8, GpuModel: "b200", FormFactor: "sxm6"},
+}
+
+func TestParseInstanceTypeName(t *testing.T) {
+	for name, expected := range lambdaInstanceTypeNames {
+		t.Run(name, func(t *testing.T) {
+			expected.Name = name
+
+			got, err := parseInstanceTypeName(name)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != expected {
+				t.Errorf("expected %+v, got %+v", expected, got)
+			}
+			if _, ok := gpuClassRanks[got.GpuModel]; !ok {
+				t.Errorf("GPU model %q has no class rank", got.GpuModel)
+			}
+		})
+	}
+}
+
+func TestParseInstanceTypeName_invalid(t *testing.T) {
+	for _, name := range []string{"", "gpu_0x_a10", "gpu_x_a10", "a100", "gpu_1x_", "GPU_1X_A10", "gpu_1x_a10 "} {
+		if _, err := parseInstanceTypeName(name); err == nil {
+			t.Errorf("expected an error for %q", name)
+		}
+	}
+}
+
+func TestCompareInstanceTypes(t *testing.T) {
+	// Each name ranks strictly above the one before it
+	ordered := []string{
+		"gpu_1x_rtx6000",
+		"gpu_8x_v100",
+		"gpu_1x_a10",
+		"gpu_1x_a6000",
+		"gpu_4x_a6000",
+		"gpu_1x_a100",
+		"gpu_2x_a100_pcie",
+		"gpu_1x_a100_sxm4",
+		"gpu_8x_a100_80gb_sxm4",
+		"gpu_1x_h100_pcie",
+		"gpu_1x_h100_sxm5",
+		"gpu_8x_h100_sxm5",
+		"gpu_1x_gh200",
+		"gpu_1x_b200_sxm6",
+		"gpu_8x_b200_sxm6",
+	}
+
+	for i := 1; i < len(ordered); i++ {
+		lower, _ := parseInstanceTypeName(ordered[i-1])
+		higher, _ := parseInstanceTypeName(ordered[i])
+
+		if got, err := compareInstanceTypes(lower, higher); err != nil || got != -1 {
+			t.Errorf("compare(%s, %s): expected -1, got %d (error: %v)", lower.Name, higher.Name, got, err)
+		}
+		if got, err := compareInstanceTypes(higher, lower); err != nil || got != 1 {
+			t.Errorf("compare(%s, %s): expected 1, got %d (error: %v)", higher.Name, lower.Name, got, err)
+		}
+	}
+
+	for name := range lambdaInstanceTypeNames {
+		parsed, _ := parseInstanceTypeName(name)
+		if got, err := compareInstanceTypes(parsed, parsed); err != nil || got != 0 {
+			t.Errorf("compare(%s, %s): expected 0, got %d (error: %v)", name, name, got, err)
+		}
+	}
+
+	unknown, _ := parseInstanceTypeName("gpu_1x_z9000")
+	known, _ := parseInstanceTypeName("gpu_1x_a10")
+	if _, err := compareInstanceTypes(unknown, known); err == nil {
+		t.Error("expected an error for an unknown GPU model")
+	}
+}
+
+func TestParseInstanceTypeFunction(t *testing.T) {
+	ctx := context.Background()
+
+	resp := function.RunResponse{
+		Result: function.NewResultData(types.ObjectUnknown(parsedInstanceTypeAttrTypes)),
+	}
+	NewParseInstanceTypeFunction().Run(ctx, function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{types.StringValue("gpu_8x_h100_sxm5")}),
+	}, &resp)
+
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+
+	expected := types.ObjectValueMust(parsedInstanceTypeAttrTypes, map[string]attr.Value{
+		"name":           types.StringValue("gpu_8x_h100_sxm5"),
+		"gpu_count":      types.Int64Value(8),
+		"gpu_model":      types.StringValue("h100"),
+		"form_factor":    types.StringValue("sxm5"),
+		"gpu_memory_gib": types.Int64Null(),
+		"variant":        types.StringNull(),
+	})
+	if !resp.Result.Value().Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, resp.Result.Value())
+	}
+}
+
+func TestCompareInstanceTypesFunction(t *testing.T) {
+	ctx := context.Background()
+
+	resp := function.RunResponse{
+		Result: function.NewResultData(types.Int64Unknown()),
+	}
+	NewCompareInstanceTypesFunction().Run(ctx, function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{
+			types.StringValue("gpu_8x_a100_80gb_sxm4"),
+			types.StringValue("gpu_1x_h100_pcie"),
+		}),
+	}, &resp)
+
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error)
+	}
+	if expected := types.Int64Value(-1); !resp.Result.Value().Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, resp.Result.Value())
+	}
+
+	resp = function.RunResponse{
+		Result: function.NewResultData(types.Int64Unknown()),
+	}
+	NewCompareInstanceTypesFunction().Run(ctx, function.RunRequest{
+		Arguments: function.NewArgumentsData([]attr.Value{
+			types.StringValue("gpu_1x_a10"),
+			types.StringValue("not_a_type"),
+		}),
+	}, &resp)
+
+	if resp.Error == nil || resp.Error.FunctionArgument == nil || *resp.Error.FunctionArgument != 1 {
+		t.Errorf("expected an error for argument 1, got %v", resp.Error)
+	}
+}
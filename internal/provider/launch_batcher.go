@@ -0,0 +1,285 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// defaultLaunchBatchWindow is how long a launch waits for identical launches
+// to join it.
+const defaultLaunchBatchWindow = 250 * time.Millisecond
+
+// launchClient is the part of the API that the launch batcher uses.
+type launchClient interface {
+	LaunchInstances(ctx context.Context, launchReq LaunchRequest) ([]string, error)
+	RenameInstance(ctx context.Context, instanceId string, name string) error
+	TerminateInstances(ctx context.Context, instanceIds []string) error
+}
+
+// launchBatcher coalesces identical launches made at about the same time into
+// one launch with a quantity, so that many instances created in one apply do
+// not race each other for capacity. Launches are identical when every field of
+// the request but the name matches. A batched launch names every instance
+// alike, so when the names differ the instances are launched unnamed and
+// renamed one by one.
+type launchBatcher struct {
+	window time.Duration
+	client launchClient
+
+	mu      sync.Mutex
+	pending map[string]*launchBatch
+}
+
+// launchBatch is a launch request collecting waiters until it is sent.
+type launchBatch struct {
+	ctx     context.Context
+	req     LaunchRequest
+	waiters []*launchWaiter
+}
+
+// launchWaiter is a launch waiting for its instance.
+type launchWaiter struct {
+	name   *string
+	result chan launchResult
+}
+
+type launchResult struct {
+	instanceId string
+	err        error
+}
+
+func newLaunchBatcher(window time.Duration, client launchClient) *launchBatcher {
+	return &launchBatcher{
+		window:  window,
+		client:  client,
+		pending: make(map[string]*launchBatch),
+	}
+}
+
+// launchOne launches a single instance, possibly as part of a batch, and
+// returns its ID.
+func (b *launchBatcher) launchOne(ctx context.Context, req LaunchRequest) (string, error) {
+	req.Quantity = 0
+	key, err := launchBatchKey(req)
+	if err != nil {
+		return "", err
+	}
+
+	waiter := &launchWaiter{name: req.Name, result: make(chan launchResult, 1)}
+	req.Name = nil
+
+	b.mu.Lock()
+	batch, ok := b.pending[key]
+	if !ok {
+		// The batch outlives the launch that started it, since others
+		// depend on it
+		batch = &launchBatch{ctx: context.WithoutCancel(ctx), req: req}
+		b.pending[key] = batch
+		time.AfterFunc(b.window, func() { b.flush(key) })
+	}
+	batch.waiters = append(batch.waiters, waiter)
+	b.mu.Unlock()
+
+	select {
+	case r := <-waiter.result:
+		return r.instanceId, r.err
+	case <-ctx.Done():
+	}
+
+	// Leave the batch if it has not been sent. Once it has, wait for the
+	// result so that an instance launched for this resource is not lost.
+	b.mu.Lock()
+	if b.pending[key] == batch {
+		for i, other := range batch.waiters {
+			if other == waiter {
+				batch.waiters = append(batch.waiters[:i], batch.waiters[i+1:]...)
+				break
+			}
+		}
+		b.mu.Unlock()
+		return "", ctx.Err()
+	}
+	b.mu.Unlock()
+
+	r := <-waiter.result
+	return r.instanceId, r.err
+}
+
+// flush sends a batch and hands the launched instances to its waiters.
+func (b *launchBatcher) flush(key string) {
+	b.mu.Lock()
+	batch := b.pending[key]
+	delete(b.pending, key)
+	b.mu.Unlock()
+
+	if batch == nil || len(batch.waiters) == 0 {
+		return
+	}
+
+	ctx := batch.ctx
+	waiters := batch.waiters
+
+	if len(waiters) == 1 {
+		b.launchIndividually(ctx, batch.req, waiters)
+		return
+	}
+
+	req := batch.req
+	req.Quantity = len(waiters)
+
+	// With a single name every instance can be named at launch
+	named := sameName(waiters)
+	if named {
+		req.Name = waiters[0].name
+	}
+
+	tflog.Info(ctx, "Coalescing identical launches into one", map[string]interface{}{
+		"instance_type_name": req.InstanceTypeName,
+		"region_name":        req.RegionName,
+		"quantity":           req.Quantity,
+	})
+
+	instanceIds, err := b.client.LaunchInstances(ctx, req)
+	if err != nil {
+		// There may be capacity for some of the instances but not all, so
+		// let each launch take its chance
+		if isInsufficientCapacity(err) {
+			tflog.Info(ctx, "Insufficient capacity for the batched launch, launching individually", map[string]interface{}{
+				"quantity": req.Quantity,
+			})
+			b.launchIndividually(ctx, batch.req, waiters)
+			return
+		}
+
+		for _, waiter := range waiters {
+			waiter.result <- launchResult{err: fmt.Errorf("batched launch of %d instances failed: %w", req.Quantity, err)}
+		}
+		return
+	}
+
+	if len(instanceIds) > len(waiters) {
+		b.terminateExtra(ctx, instanceIds[len(waiters):])
+		instanceIds = instanceIds[:len(waiters)]
+	}
+
+	var unnamed []*launchWaiter
+	for i, instanceId := range instanceIds {
+		waiter := waiters[i]
+		if !named && waiter.name != nil {
+			if err := b.client.RenameInstance(ctx, instanceId, *waiter.name); err != nil {
+				// Rather than hand out an instance with the wrong name,
+				// replace it with one launched under the right name
+				tflog.Warn(ctx, "Unable to name an instance of a batched launch, launching it individually", map[string]interface{}{
+					"instance_id": instanceId,
+					"error":       err.Error(),
+				})
+				b.terminateExtra(ctx, []string{instanceId})
+				unnamed = append(unnamed, waiter)
+				continue
+			}
+		}
+		waiter.result <- launchResult{instanceId: instanceId}
+	}
+
+	if len(instanceIds) < len(waiters) {
+		tflog.Warn(ctx, "Batched launch returned fewer instances than requested, launching the rest individually", map[string]interface{}{
+			"requested": len(waiters),
+			"launched":  len(instanceIds),
+		})
+		unnamed = append(unnamed, waiters[len(instanceIds):]...)
+	}
+	if len(unnamed) > 0 {
+		b.launchIndividually(ctx, batch.req, unnamed)
+	}
+}
+
+// launchIndividually launches one instance for each waiter, concurrently,
+// under the waiter's name.
+func (b *launchBatcher) launchIndividually(ctx context.Context, req LaunchRequest, waiters []*launchWaiter) {
+	var wg sync.WaitGroup
+	for _, waiter := range waiters {
+		wg.Add(1)
+		go func(waiter *launchWaiter) {
+			defer wg.Done()
+
+			launchReq := req
+			launchReq.Name = waiter.name
+
+			instanceIds, err := b.client.LaunchInstances(ctx, launchReq)
+			if err != nil {
+				waiter.result <- launchResult{err: err}
+				return
+			}
+			if len(instanceIds) > 1 {
+				b.terminateExtra(ctx, instanceIds[1:])
+			}
+			waiter.result <- launchResult{instanceId: instanceIds[0]}
+		}(waiter)
+	}
+	wg.Wait()
+}
+
+// terminateExtra terminates instances that a launch returned but no resource
+// asked for, so that they do not run unmanaged.
+func (b *launchBatcher) terminateExtra(ctx context.Context, instanceIds []string) {
+	tflog.Warn(ctx, "Terminating instances that no resource asked for", map[string]interface{}{"instance_ids": instanceIds})
+
+	if err := b.client.TerminateInstances(ctx, instanceIds); err != nil {
+		tflog.Error(ctx, "Unable to terminate instances that no resource asked for, terminate them by hand", map[string]interface{}{
+			"instance_ids": instanceIds,
+			"error":        err.Error(),
+		})
+	}
+}
+
+// sameName reports whether every waiter asked for the same name, or none.
+func sameName(waiters []*launchWaiter) bool {
+	for _, waiter := range waiters[1:] {
+		if (waiter.name == nil) != (waiters[0].name == nil) ||
+			(waiter.name != nil && *waiter.name != *waiters[0].name) {
+			return false
+		}
+	}
+	return true
+}
+
+// launchBatchKey identifies launches that can share a batch. The name is left
+// out, since batched instances can be renamed, and the order of SSH keys and
+// file systems does not matter to the API.
+func launchBatchKey(req LaunchRequest) (string, error) {
+	req.Name = nil
+	req.SshKeyNames = sortedCopy(req.SshKeyNames)
+	req.FileSystemNames = sortedCopy(req.FileSystemNames)
+
+	key, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	return string(key), nil
+}
+
+func sortedCopy(values []string) []string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// launchInstance launches a single instance and returns its ID, coalescing it
+// with identical launches when the provider batches launches.
+func (c *ProviderConfig) launchInstance(ctx context.Context, req LaunchRequest) (string, error) {
+	if c.launches != nil {
+		return c.launches.launchOne(ctx, req)
+	}
+
+	instanceIds, err := c.LaunchInstances(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	return instanceIds[0], nil
+}
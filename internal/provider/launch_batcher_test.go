@@ -0,0 +1,266 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/albertocavalcante/terraform-provider-lambda/internal/fakelambda"
+)
+
+// launchConcurrently launches n instances through client at once, returning
+// the instance IDs and errors in launch order.
+func launchConcurrently(client *ProviderConfig, n int, req LaunchRequest) ([]string, []error) {
+	ids := make([]string, n)
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ids[i], errs[i] = client.launchInstance(context.Background(), req)
+		}(i)
+	}
+	wg.Wait()
+
+	return ids, errs
+}
+
+func countLaunchRequests(fake *fakelambda.Server) int {
+	var launches int
+	for _, req := range fake.Requests() {
+		if req.Path == "/api/v1/instance-operations/launch" {
+			launches++
+		}
+	}
+	return launches
+}
+
+func newLaunchBatcherTestClient(t *testing.T) (*ProviderConfig, *fakelambda.Server) {
+	t.Helper()
+
+	fake := fakelambda.New(fakelambda.Options{})
+	if _, err := fake.AddSshKey("batch"); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client := &ProviderConfig{ApiKey: "test", Endpoint: server.URL, HTTPClient: &http.Client{}}
+	client.launches = newLaunchBatcher(100*time.Millisecond, client)
+
+	return client, fake
+}
+
+func TestLaunchBatcher(t *testing.T) {
+	req := LaunchRequest{
+		RegionName:       "us-east-1",
+		InstanceTypeName: "gpu_1x_a10",
+		SshKeyNames:      []string{"batch"},
+	}
+
+	t.Run("identical launches share one call", func(t *testing.T) {
+		client, fake := newLaunchBatcherTestClient(t)
+
+		ids, errs := launchConcurrently(client, 6, req)
+
+		seen := make(map[string]bool)
+		for i := range ids {
+			if errs[i] != nil {
+				t.Fatal(errs[i])
+			}
+			if ids[i] == "" || seen[ids[i]] {
+				t.Fatalf("expected distinct instance IDs, got %v", ids)
+			}
+			seen[ids[i]] = true
+		}
+
+		if got := countLaunchRequests(fake); got != 1 {
+			t.Errorf("expected a single launch call, got %d", got)
+		}
+		if got := len(fake.Instances()); got != 6 {
+			t.Errorf("expected 6 instances, got %d", got)
+		}
+	})
+
+	t.Run("launches with different names share one call", func(t *testing.T) {
+		client, fake := newLaunchBatcherTestClient(t)
+
+		names := []string{"first", "second", "third"}
+		ids := make([]string, len(names))
+
+		var wg sync.WaitGroup
+		for i := range names {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				launchReq := req
+				launchReq.Name = &names[i]
+
+				var err error
+				if ids[i], err = client.launchInstance(context.Background(), launchReq); err != nil {
+					t.Error(err)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		if got := countLaunchRequests(fake); got != 1 {
+			t.Errorf("expected a single launch call, got %d", got)
+		}
+
+		instanceNames := make(map[string]string)
+		for _, instance := range fake.Instances() {
+			instanceNames[instance.Id] = instance.Name
+		}
+		for i, name := range names {
+			if got := instanceNames[ids[i]]; got != name {
+				t.Errorf("expected instance %s to be named %q, got %q", ids[i], name, got)
+			}
+		}
+	})
+
+	t.Run("different launches are not batched", func(t *testing.T) {
+		client, fake := newLaunchBatcherTestClient(t)
+
+		other := req
+		other.RegionName = "us-west-1"
+
+		var wg sync.WaitGroup
+		for _, launchReq := range []LaunchRequest{req, other} {
+			wg.Add(1)
+			go func(launchReq LaunchRequest) {
+				defer wg.Done()
+				if _, err := client.launchInstance(context.Background(), launchReq); err != nil {
+					t.Error(err)
+				}
+			}(launchReq)
+		}
+		wg.Wait()
+
+		if got := countLaunchRequests(fake); got != 2 {
+			t.Errorf("expected 2 launch calls, got %d", got)
+		}
+	})
+
+	t.Run("insufficient capacity falls back to individual launches", func(t *testing.T) {
+		client, fake := newLaunchBatcherTestClient(t)
+		fake.SetCapacity("gpu_1x_a10", "us-east-1", 3)
+
+		_, errs := launchConcurrently(client, 5, req)
+
+		var launched, capacityErrors int
+		for _, err := range errs {
+			switch {
+			case err == nil:
+				launched++
+			case isInsufficientCapacity(err):
+				capacityErrors++
+			default:
+				t.Errorf("unexpected error %s", err)
+			}
+		}
+
+		if launched != 3 || capacityErrors != 2 {
+			t.Errorf("expected 3 launches and 2 capacity errors, got %d and %d", launched, capacityErrors)
+		}
+		if got := countLaunchRequests(fake); got != 6 {
+			t.Errorf("expected the batch and 5 individual launch calls, got %d", got)
+		}
+	})
+
+	t.Run("other errors reach every launch", func(t *testing.T) {
+		client, fake := newLaunchBatcherTestClient(t)
+		fake.InjectFault(fakelambda.ServerErrorFault(1, http.StatusInternalServerError))
+
+		_, errs := launchConcurrently(client, 3, req)
+		for _, err := range errs {
+			if err == nil {
+				t.Error("expected every launch in the batch to fail")
+			}
+		}
+	})
+
+	t.Run("extra instances are terminated", func(t *testing.T) {
+		client, fake := newLaunchBatcherTestClient(t)
+
+		// The API launches one instance more than asked for
+		extra := &extraLaunchClient{ProviderConfig: client}
+		client.launches = newLaunchBatcher(100*time.Millisecond, extra)
+
+		_, errs := launchConcurrently(client, 2, req)
+		for _, err := range errs {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if extra.extraId == "" {
+			t.Fatal("expected an extra instance to be launched")
+		}
+		for _, instance := range fake.Instances() {
+			if instance.Id == extra.extraId && instance.Status != fakelambda.StatusTerminating {
+				t.Errorf("expected the extra instance to be terminated, got status %s", instance.Status)
+			}
+		}
+	})
+
+	t.Run("a cancelled launch leaves the batch", func(t *testing.T) {
+		client, fake := newLaunchBatcherTestClient(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := client.launchInstance(ctx, req); err == nil {
+			t.Fatal("expected the cancelled launch to fail")
+		}
+
+		time.Sleep(200 * time.Millisecond)
+		if got := countLaunchRequests(fake); got != 0 {
+			t.Errorf("expected no launch calls, got %d", got)
+		}
+	})
+
+	t.Run("a launch cancelled after the batch is sent gets its instance", func(t *testing.T) {
+		client, fake := newLaunchBatcherTestClient(t)
+		fake.InjectFault(fakelambda.Fault{
+			Method:     http.MethodPost,
+			PathPrefix: "/api/v1/instance-operations/launch",
+			Latency:    300 * time.Millisecond,
+		})
+
+		// Cancelled while the batch, sent after 100ms, is launching
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+
+		instanceId, err := client.launchInstance(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ctx.Err() == nil {
+			t.Fatal("expected the launch to outlast the context")
+		}
+		if instances := fake.Instances(); len(instances) != 1 || instances[0].Id != instanceId {
+			t.Errorf("expected the launched instance %s, got %v", instanceId, instances)
+		}
+	})
+}
+
+// extraLaunchClient launches one instance more than each launch asks for.
+type extraLaunchClient struct {
+	*ProviderConfig
+	extraId string
+}
+
+func (c *extraLaunchClient) LaunchInstances(ctx context.Context, launchReq LaunchRequest) ([]string, error) {
+	launchReq.Quantity = max(launchReq.Quantity, 1) + 1
+	instanceIds, err := c.ProviderConfig.LaunchInstances(ctx, launchReq)
+	if err == nil {
+		c.extraId = instanceIds[len(instanceIds)-1]
+	}
+	return instanceIds, err
+}
@@ -0,0 +1,155 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// apiLogSubsystem is the tflog subsystem API calls are logged under. Its level
+// can be set on its own with TF_LOG_PROVIDER_LAMBDA_API.
+const apiLogSubsystem = "lambda_api"
+
+// logMask replaces masked values in logs, matching tflog's own masking.
+const logMask = "***"
+
+// apiLogMaskedKeys are JSON object keys whose values are masked in logged
+// bodies.
+var apiLogMaskedKeys = map[string]bool{
+	"private_key": true,
+	"user_data":   true,
+}
+
+// requestIdHeaders are the response headers that may carry the ID the API
+// assigned to a request, in order of preference.
+var requestIdHeaders = []string{"X-Request-Id", "X-Lambda-Request-Id"}
+
+// retryCountKey is the context key for the number of times a call has been
+// retried.
+type retryCountKey struct{}
+
+// withRetryCount records in ctx how many earlier attempts a call has made.
+func withRetryCount(ctx context.Context, retries int) context.Context {
+	return context.WithValue(ctx, retryCountKey{}, retries)
+}
+
+func retryCount(ctx context.Context) int {
+	retries, _ := ctx.Value(retryCountKey{}).(int)
+	return retries
+}
+
+// loggingTransport logs every API call to the lambda_api subsystem: a summary
+// at DEBUG, and the headers and bodies at TRACE with secrets masked.
+type loggingTransport struct {
+	next http.RoundTripper
+}
+
+func newLoggingTransport(next http.RoundTripper) *loggingTransport {
+	return &loggingTransport{next: next}
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := tflog.NewSubsystem(req.Context(), apiLogSubsystem)
+
+	// Mask the API key wherever it might show up, not just in the header
+	if token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok && token != "" {
+		ctx = tflog.SubsystemMaskAllFieldValuesStrings(ctx, apiLogSubsystem, token)
+	}
+
+	fields := map[string]interface{}{
+		"method":      req.Method,
+		"path":        req.URL.Path,
+		"retry_count": retryCount(ctx),
+	}
+
+	reqBody, err := readAndRestoreBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	tflog.SubsystemTrace(ctx, apiLogSubsystem, "Sending API request", mergeFields(fields, map[string]interface{}{
+		"headers": maskedHeaders(req.Header),
+		"body":    maskedBody(reqBody),
+	}))
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	fields["latency_ms"] = time.Since(start).Milliseconds()
+
+	if err != nil {
+		tflog.SubsystemDebug(ctx, apiLogSubsystem, "API request failed", mergeFields(fields, map[string]interface{}{
+			"error": err.Error(),
+		}))
+		return nil, err
+	}
+
+	fields["status"] = resp.StatusCode
+	for _, header := range requestIdHeaders {
+		if requestId := resp.Header.Get(header); requestId != "" {
+			fields["request_id"] = requestId
+			break
+		}
+	}
+
+	tflog.SubsystemDebug(ctx, apiLogSubsystem, "API request", fields)
+
+	respBody, err := readAndRestoreBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	tflog.SubsystemTrace(ctx, apiLogSubsystem, "Received API response", mergeFields(fields, map[string]interface{}{
+		"headers": maskedHeaders(resp.Header),
+		"body":    maskedBody(respBody),
+	}))
+
+	return resp, nil
+}
+
+// maskedHeaders flattens headers for logging, masking credentials.
+func maskedHeaders(header http.Header) map[string]string {
+	out := make(map[string]string, len(header))
+	for name, values := range header {
+		switch http.CanonicalHeaderKey(name) {
+		case "Authorization", "Cookie", "Set-Cookie":
+			out[name] = logMask
+		default:
+			out[name] = strings.Join(values, ", ")
+		}
+	}
+	return out
+}
+
+// maskedBody returns a body for logging with secret JSON values masked. A
+// body that is not JSON is logged as is.
+func maskedBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return string(body)
+	}
+
+	masked, err := json.Marshal(scrubJSON(value, apiLogMaskedKeys, logMask))
+	if err != nil {
+		return string(body)
+	}
+	return string(masked)
+}
+
+func mergeFields(base, extra map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(extra))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range extra {
+		merged[key] = value
+	}
+	return merged
+}
@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+
+	"github.com/albertocavalcante/terraform-provider-lambda/internal/fakelambda"
+)
+
+func TestLoggingTransport(t *testing.T) {
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+
+	fake := fakelambda.New(fakelambda.Options{})
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := &ProviderConfig{
+		ApiKey:     "super-secret-token",
+		Endpoint:   server.URL,
+		HTTPClient: &http.Client{Transport: newLoggingTransport(http.DefaultTransport)},
+	}
+
+	created, err := client.CreateSshKey(ctx, CreateSshKeyRequest{Name: "logged"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A launch with user_data, which is rejected since the key is unknown
+	_, _ = client.LaunchInstances(ctx, LaunchRequest{
+		RegionName:       "us-east-1",
+		InstanceTypeName: "gpu_1x_a10",
+		SshKeyNames:      []string{"missing"},
+		UserData:         "#cloud-config\npassword: hunter2\n",
+	})
+
+	for _, secret := range []string{"super-secret-token", created.PrivateKey, "PRIVATE KEY", "hunter2"} {
+		if strings.Contains(output.String(), secret) {
+			t.Errorf("logs contain secret %q", secret)
+		}
+	}
+
+	entries, err := tflogtest.MultilineJSONDecode(&output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var summaries []map[string]interface{}
+	var bodies int
+	for _, entry := range entries {
+		if entry["@module"] != "provider."+apiLogSubsystem {
+			t.Errorf("unexpected module in %v", entry)
+		}
+		switch entry["@message"] {
+		case "API request":
+			summaries = append(summaries, entry)
+		case "Sending API request", "Received API response":
+			if entry["@level"] != "trace" {
+				t.Errorf("expected bodies at trace, got %v", entry["@level"])
+			}
+			bodies++
+		}
+	}
+
+	if len(summaries) != 2 || bodies != 4 {
+		t.Fatalf("expected 2 summaries and 4 body entries, got %d and %d", len(summaries), bodies)
+	}
+
+	create := summaries[0]
+	if create["@level"] != "debug" || create["method"] != "POST" || create["path"] != "/api/v1/ssh-keys" {
+		t.Errorf("unexpected summary %v", create)
+	}
+	if create["status"] != float64(http.StatusOK) || create["retry_count"] != float64(0) {
+		t.Errorf("unexpected status or retry count in %v", create)
+	}
+	if _, ok := create["latency_ms"]; !ok {
+		t.Errorf("expected latency_ms in %v", create)
+	}
+	if id, _ := create["request_id"].(string); id == "" {
+		t.Errorf("expected request_id in %v", create)
+	}
+
+	if summaries[1]["status"] != float64(http.StatusBadRequest) {
+		t.Errorf("expected the failed launch to be logged with its status, got %v", summaries[1])
+	}
+}
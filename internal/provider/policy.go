@@ -0,0 +1,114 @@
+package provider
+
+import (
+	"fmt"
+	pathpkg "path"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Policy holds the organisation guardrails set in the provider block. A zero
+// value allows everything.
+type Policy struct {
+	AllowedRegions []string
+	// AllowedInstanceTypes are glob patterns such as "gpu_1x_*".
+	AllowedInstanceTypes []string
+	MaxGpusPerInstance   int64
+	NamePattern          *regexp.Regexp
+}
+
+// checkRegion reports an error at attrPath if the region is not allowed.
+func (p *Policy) checkRegion(attrPath path.Path, regionName string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if len(p.AllowedRegions) == 0 || containsString(p.AllowedRegions, regionName) {
+		return diags
+	}
+
+	diags.AddAttributeError(
+		attrPath,
+		"Region not allowed",
+		fmt.Sprintf("Region %q is not allowed by the provider's allowed_regions. Allowed regions: %s.",
+			regionName, strings.Join(p.AllowedRegions, ", ")),
+	)
+	return diags
+}
+
+// checkInstanceType reports an error at attrPath if the instance type does not
+// match allowed_instance_types or has more GPUs than max_gpus_per_instance.
+func (p *Policy) checkInstanceType(attrPath path.Path, instanceTypeName string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if len(p.AllowedInstanceTypes) > 0 && !matchesAnyGlob(p.AllowedInstanceTypes, instanceTypeName) {
+		diags.AddAttributeError(
+			attrPath,
+			"Instance type not allowed",
+			fmt.Sprintf("Instance type %q does not match any of the provider's allowed_instance_types: %s.",
+				instanceTypeName, strings.Join(p.AllowedInstanceTypes, ", ")),
+		)
+	}
+
+	if p.MaxGpusPerInstance > 0 {
+		parsed, err := parseInstanceTypeName(instanceTypeName)
+		switch {
+		case err != nil:
+			diags.AddAttributeError(
+				attrPath,
+				"Unable to check GPU count",
+				fmt.Sprintf("The provider's max_gpus_per_instance is set, but the GPU count of the instance type cannot be determined: %s", err),
+			)
+		case parsed.GpuCount > p.MaxGpusPerInstance:
+			diags.AddAttributeError(
+				attrPath,
+				"Too many GPUs per instance",
+				fmt.Sprintf("Instance type %q has %d GPUs, which exceeds the provider's max_gpus_per_instance of %d.",
+					instanceTypeName, parsed.GpuCount, p.MaxGpusPerInstance),
+			)
+		}
+	}
+
+	return diags
+}
+
+// checkName reports an error at attrPath if name_pattern is set and the name
+// is missing or does not match it.
+func (p *Policy) checkName(attrPath path.Path, name types.String) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if p.NamePattern == nil || name.IsUnknown() {
+		return diags
+	}
+
+	if name.IsNull() {
+		diags.AddAttributeError(
+			attrPath,
+			"Name required",
+			fmt.Sprintf("The provider's name_pattern requires a name matching %q.", p.NamePattern),
+		)
+		return diags
+	}
+
+	if !p.NamePattern.MatchString(name.ValueString()) {
+		diags.AddAttributeError(
+			attrPath,
+			"Name does not match name_pattern",
+			fmt.Sprintf("Name %q does not match the provider's name_pattern %q.", name.ValueString(), p.NamePattern),
+		)
+	}
+
+	return diags
+}
+
+func matchesAnyGlob(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		// Patterns were validated when the provider was configured
+		if ok, _ := pathpkg.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
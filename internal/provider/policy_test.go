@@ -0,0 +1,260 @@
+package provider
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// errorSummaries returns the summaries of the errors among diags, joined for
+// comparison.
+func errorSummaries(diags diag.Diagnostics) string {
+	return strings.Join(diagnosticSummaries(diags, diag.SeverityError), ", ")
+}
+
+func TestPolicy(t *testing.T) {
+	policy := Policy{
+		AllowedRegions:       []string{"us-east-1", "us-west-1"},
+		AllowedInstanceTypes: []string{"gpu_1x_*", "gpu_8x_a100*"},
+		MaxGpusPerInstance:   4,
+		NamePattern:          regexp.MustCompile(`^team-ml-`),
+	}
+	attrPath := path.Root("test")
+
+	for name, tc := range map[string]struct {
+		policy Policy
+		check  func(*Policy) diag.Diagnostics
+		want   string
+	}{
+		"allowed region": {
+			policy: policy,
+			check:  func(p *Policy) diag.Diagnostics { return p.checkRegion(attrPath, "us-west-1") },
+		},
+		"region not allowed": {
+			policy: policy,
+			check:  func(p *Policy) diag.Diagnostics { return p.checkRegion(attrPath, "europe-central-1") },
+			want:   "Region not allowed",
+		},
+		"every region allowed without allowed_regions": {
+			check: func(p *Policy) diag.Diagnostics { return p.checkRegion(attrPath, "europe-central-1") },
+		},
+		"instance type matching a glob": {
+			policy: policy,
+			check:  func(p *Policy) diag.Diagnostics { return p.checkInstanceType(attrPath, "gpu_1x_h100_pcie") },
+		},
+		"glob matches the whole name": {
+			policy: Policy{AllowedInstanceTypes: []string{"gpu_1x_*"}},
+			check:  func(p *Policy) diag.Diagnostics { return p.checkInstanceType(attrPath, "gpu_2x_a100") },
+			want:   "Instance type not allowed",
+		},
+		"instance type matching no glob": {
+			policy: policy,
+			check:  func(p *Policy) diag.Diagnostics { return p.checkInstanceType(attrPath, "gpu_8x_h100_sxm5") },
+			want:   "Instance type not allowed, Too many GPUs per instance",
+		},
+		"GPU count within max_gpus_per_instance": {
+			policy: Policy{MaxGpusPerInstance: 4},
+			check:  func(p *Policy) diag.Diagnostics { return p.checkInstanceType(attrPath, "gpu_4x_a100") },
+		},
+		"GPU count parsed from the name exceeds max_gpus_per_instance": {
+			policy: Policy{MaxGpusPerInstance: 4},
+			check:  func(p *Policy) diag.Diagnostics { return p.checkInstanceType(attrPath, "gpu_8x_a100_80gb_sxm4") },
+			want:   "Too many GPUs per instance",
+		},
+		"GPU count that cannot be parsed": {
+			policy: Policy{MaxGpusPerInstance: 4},
+			check:  func(p *Policy) diag.Diagnostics { return p.checkInstanceType(attrPath, "cpu_only") },
+			want:   "Unable to check GPU count",
+		},
+		"unparsable name is fine without max_gpus_per_instance": {
+			check: func(p *Policy) diag.Diagnostics { return p.checkInstanceType(attrPath, "cpu_only") },
+		},
+		"name matching name_pattern": {
+			policy: policy,
+			check:  func(p *Policy) diag.Diagnostics { return p.checkName(attrPath, types.StringValue("team-ml-trainer")) },
+		},
+		"name not matching name_pattern": {
+			policy: policy,
+			check:  func(p *Policy) diag.Diagnostics { return p.checkName(attrPath, types.StringValue("trainer")) },
+			want:   "Name does not match name_pattern",
+		},
+		"null name with name_pattern": {
+			policy: policy,
+			check:  func(p *Policy) diag.Diagnostics { return p.checkName(attrPath, types.StringNull()) },
+			want:   "Name required",
+		},
+		"empty name with name_pattern": {
+			policy: policy,
+			check:  func(p *Policy) diag.Diagnostics { return p.checkName(attrPath, types.StringValue("")) },
+			want:   "Name does not match name_pattern",
+		},
+		"empty name matching name_pattern": {
+			policy: Policy{NamePattern: regexp.MustCompile(`^[a-z-]*$`)},
+			check:  func(p *Policy) diag.Diagnostics { return p.checkName(attrPath, types.StringValue("")) },
+		},
+		"unknown name is checked at apply": {
+			policy: policy,
+			check:  func(p *Policy) diag.Diagnostics { return p.checkName(attrPath, types.StringUnknown()) },
+		},
+		"null name without name_pattern": {
+			check: func(p *Policy) diag.Diagnostics { return p.checkName(attrPath, types.StringNull()) },
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := errorSummaries(tc.check(&tc.policy)); got != tc.want {
+				t.Errorf("expected errors %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestMatchesAnyGlob(t *testing.T) {
+	patterns := []string{"gpu_1x_*", "gpu_8x_a100*"}
+
+	for name, want := range map[string]bool{
+		"gpu_1x_a10":            true,
+		"gpu_1x_h100_pcie":      true,
+		"gpu_8x_a100":           true,
+		"gpu_8x_a100_80gb_sxm4": true,
+		"gpu_8x_h100_sxm5":      false,
+		"gpu_2x_a100":           false,
+		"xgpu_1x_a10":           false,
+		"":                      false,
+	} {
+		if got := matchesAnyGlob(patterns, name); got != want {
+			t.Errorf("matchesAnyGlob(%q) = %t, want %t", name, got, want)
+		}
+	}
+}
+
+func TestLambdaProviderModelPolicy(t *testing.T) {
+	ctx := context.Background()
+	stringList := func(values ...string) types.List {
+		list, diags := types.ListValueFrom(ctx, types.StringType, values)
+		if diags.HasError() {
+			t.Fatal(diags)
+		}
+		return list
+	}
+
+	t.Run("valid settings", func(t *testing.T) {
+		model := LambdaProviderModel{
+			AllowedRegions:       stringList("us-east-1"),
+			AllowedInstanceTypes: stringList("gpu_1x_*"),
+			MaxGpusPerInstance:   types.Int64Value(2),
+			NamePattern:          types.StringValue("^team-"),
+		}
+
+		policy, diags := model.policy(ctx)
+		if diags.HasError() {
+			t.Fatal(diags)
+		}
+		if len(policy.AllowedRegions) != 1 || len(policy.AllowedInstanceTypes) != 1 || policy.MaxGpusPerInstance != 2 {
+			t.Errorf("expected the settings to carry over, got %+v", policy)
+		}
+		if policy.NamePattern == nil || !policy.NamePattern.MatchString("team-a") {
+			t.Errorf("expected name_pattern to be compiled, got %v", policy.NamePattern)
+		}
+	})
+
+	t.Run("no settings allow everything", func(t *testing.T) {
+		policy, diags := (&LambdaProviderModel{}).policy(ctx)
+		if diags.HasError() {
+			t.Fatal(diags)
+		}
+		if len(policy.AllowedRegions) != 0 || len(policy.AllowedInstanceTypes) != 0 || policy.MaxGpusPerInstance != 0 || policy.NamePattern != nil {
+			t.Errorf("expected a zero policy, got %+v", policy)
+		}
+	})
+
+	t.Run("invalid patterns", func(t *testing.T) {
+		model := LambdaProviderModel{
+			AllowedInstanceTypes: stringList("gpu_1x_*", "gpu_[8x"),
+			NamePattern:          types.StringValue("team-("),
+		}
+
+		_, diags := model.policy(ctx)
+		if got := errorSummaries(diags); got != "Invalid instance type pattern, Invalid name pattern" {
+			t.Errorf("expected both patterns to be rejected, got %q", got)
+		}
+		if !diags.Errors()[0].(diag.DiagnosticWithPath).Path().Equal(path.Root("allowed_instance_types").AtListIndex(1)) {
+			t.Errorf("expected the error at the invalid pattern, got %v", diags.Errors()[0])
+		}
+	})
+}
+
+func TestInstanceResourceCheckPolicy(t *testing.T) {
+	ctx := context.Background()
+	r := &InstanceResource{client: &ProviderConfig{Policy: Policy{
+		AllowedRegions:       []string{"us-east-1"},
+		AllowedInstanceTypes: []string{"gpu_1x_*"},
+		NamePattern:          regexp.MustCompile(`^team-ml-`),
+	}}}
+
+	// An instance that predates the policy, and breaks all of it
+	existing := InstanceModel{
+		Name:             types.StringValue("legacy"),
+		RegionName:       types.StringValue("us-west-1"),
+		InstanceTypeName: types.StringValue("gpu_8x_h100_sxm5"),
+	}
+
+	for name, tc := range map[string]struct {
+		plan  func(InstanceModel) InstanceModel
+		state *InstanceModel
+		want  string
+	}{
+		"new instance is checked": {
+			plan:  func(m InstanceModel) InstanceModel { return m },
+			state: nil,
+			want:  "Name does not match name_pattern, Region not allowed, Instance type not allowed",
+		},
+		"unchanged instance is not checked": {
+			plan:  func(m InstanceModel) InstanceModel { return m },
+			state: &existing,
+		},
+		"changed region is checked": {
+			plan: func(m InstanceModel) InstanceModel {
+				m.RegionName = types.StringValue("us-west-2")
+				return m
+			},
+			state: &existing,
+			want:  "Region not allowed",
+		},
+		"changed instance type is checked": {
+			plan: func(m InstanceModel) InstanceModel {
+				m.InstanceTypeName = types.StringValue("gpu_2x_a100")
+				return m
+			},
+			state: &existing,
+			want:  "Instance type not allowed",
+		},
+		"changed name is checked": {
+			plan: func(m InstanceModel) InstanceModel {
+				m.Name = types.StringValue("renamed")
+				return m
+			},
+			state: &existing,
+			want:  "Name does not match name_pattern",
+		},
+		"unknown values are checked at apply": {
+			plan: func(m InstanceModel) InstanceModel {
+				m.RegionName = types.StringUnknown()
+				m.InstanceTypeName = types.StringUnknown()
+				return m
+			},
+			state: &existing,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			plan := tc.plan(existing)
+			if got := errorSummaries(r.checkPolicy(ctx, &plan, tc.state)); got != tc.want {
+				t.Errorf("expected errors %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -4,19 +4,33 @@ import (
 	"context"
 	"fmt"
 	"net/http"
-	"os"
+	pathpkg "path"
+	"regexp"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/float64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/action"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
 	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 // Ensure LambdaProvider satisfies various provider interfaces.
 var _ provider.Provider = &LambdaProvider{}
 var _ provider.ProviderWithFunctions = &LambdaProvider{}
+var _ provider.ProviderWithActions = &LambdaProvider{}
+var _ provider.ProviderWithEphemeralResources = &LambdaProvider{}
 
 // LambdaProvider defines the provider implementation.
 type LambdaProvider struct {
@@ -28,8 +42,32 @@ type LambdaProvider struct {
 
 // LambdaProviderModel describes the provider data model.
 type LambdaProviderModel struct {
-	ApiKey   types.String `tfsdk:"api_key"`
-	Endpoint types.String `tfsdk:"endpoint"`
+	ApiKey                       types.String  `tfsdk:"api_key"`
+	ApiKeyFile                   types.String  `tfsdk:"api_key_file"`
+	Profile                      types.String  `tfsdk:"profile"`
+	SharedCredentialsFile        types.String  `tfsdk:"shared_credentials_file"`
+	CredentialProcess            types.String  `tfsdk:"credential_process"`
+	Endpoint                     types.String  `tfsdk:"endpoint"`
+	MaxHourlySpendCents          types.Int64   `tfsdk:"max_hourly_spend_cents"`
+	MaxInstancePriceCentsPerHour types.Int64   `tfsdk:"max_instance_price_cents_per_hour"`
+	AllowedRegions               types.List    `tfsdk:"allowed_regions"`
+	AllowedInstanceTypes         types.List    `tfsdk:"allowed_instance_types"`
+	MaxGpusPerInstance           types.Int64   `tfsdk:"max_gpus_per_instance"`
+	NamePattern                  types.String  `tfsdk:"name_pattern"`
+	RequestTimeout               types.String  `tfsdk:"request_timeout"`
+	MaxRetries                   types.Int64   `tfsdk:"max_retries"`
+	HTTPProxy                    types.String  `tfsdk:"http_proxy"`
+	CACertFile                   types.String  `tfsdk:"ca_cert_file"`
+	CACertPEM                    types.String  `tfsdk:"ca_cert_pem"`
+	InsecureSkipVerify           types.Bool    `tfsdk:"insecure_skip_verify"`
+	AllowInsecureHTTP            types.Bool    `tfsdk:"allow_insecure_http"`
+	UserAgentSuffix              types.String  `tfsdk:"user_agent_suffix"`
+	SkipCredentialsValidation    types.Bool    `tfsdk:"skip_credentials_validation"`
+	RequestsPerSecond            types.Float64 `tfsdk:"requests_per_second"`
+	MaxConcurrentRequests        types.Int64   `tfsdk:"max_concurrent_requests"`
+	CatalogCacheTTL              types.String  `tfsdk:"catalog_cache_ttl"`
+	CatalogCacheFile             types.String  `tfsdk:"catalog_cache_file"`
+	CatalogSnapshotFile          types.String  `tfsdk:"catalog_snapshot_file"`
 }
 
 // ProviderConfig holds the configuration for API requests
@@ -37,6 +75,31 @@ type ProviderConfig struct {
 	ApiKey     string
 	Endpoint   string
 	HTTPClient *http.Client
+
+	// Spend limits in US cents per hour; zero means no limit.
+	MaxHourlySpendCents          int64
+	MaxInstancePriceCentsPerHour int64
+
+	// Policy holds the allowlists enforced when planning resources.
+	Policy Policy
+
+	spend *spendTracker
+
+	// launches coalesces identical instance launches; nil launches each
+	// instance on its own.
+	launches *launchBatcher
+
+	// instances serves instance reads from one listing per run; nil reads
+	// each instance on its own.
+	instances *instanceSnapshot
+
+	// catalog shares the instance type catalog between resources and data
+	// sources; nil fetches it on every use.
+	catalog *catalogCache
+
+	// snapshot, when set, serves the instance type catalog in place of the
+	// API.
+	snapshot *CatalogSnapshot
 }
 
 func (p *LambdaProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -52,8 +115,140 @@ func (p *LambdaProvider) Schema(ctx context.Context, req provider.SchemaRequest,
 				Optional:            true,
 				Sensitive:           true,
 			},
+			"api_key_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a file holding the API key. Used when no api_key is set. Can also be set via the LAMBDA_CLOUD_API_KEY_FILE environment variable.",
+				Optional:            true,
+			},
+			"profile": schema.StringAttribute{
+				MarkdownDescription: "Profile in the shared credentials file to take the API key from. Can also be set via the LAMBDA_PROFILE environment variable.",
+				Optional:            true,
+			},
+			"shared_credentials_file": schema.StringAttribute{
+				MarkdownDescription: "Path to the shared credentials file. Can also be set via the LAMBDA_SHARED_CREDENTIALS_FILE environment variable. Defaults to `~/.lambda/credentials`.",
+				Optional:            true,
+			},
+			"credential_process": schema.StringAttribute{
+				MarkdownDescription: "Command that prints the API key as JSON, such as `{\"api_key\": \"...\", \"expiration\": \"2030-01-01T00:00:00Z\"}`. It is run again when the key expires. Can also be set via the LAMBDA_CLOUD_CREDENTIAL_PROCESS environment variable.",
+				Optional:            true,
+			},
+			"skip_credentials_validation": schema.BoolAttribute{
+				MarkdownDescription: "Skip checking the API key when the provider is configured, for offline or mocked use. Can also be set via the LAMBDA_CLOUD_SKIP_CREDENTIALS_VALIDATION environment variable.",
+				Optional:            true,
+			},
 			"endpoint": schema.StringAttribute{
-				MarkdownDescription: "Lambda Cloud API endpoint. Defaults to https://cloud.lambda.ai",
+				MarkdownDescription: "Lambda Cloud API endpoint. Can also be set via the LAMBDA_CLOUD_ENDPOINT environment variable. Defaults to https://cloud.lambda.ai",
+				Optional:            true,
+			},
+			"request_timeout": schema.StringAttribute{
+				MarkdownDescription: "Timeout for each attempt of an API request, such as `30s`. Can also be set via the LAMBDA_CLOUD_REQUEST_TIMEOUT environment variable. Defaults to `60s`.",
+				Optional:            true,
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"max_retries": schema.Int64Attribute{
+				MarkdownDescription: "Number of times a failed API request is retried. Rate-limited requests are always retried; server and network errors only for requests that are safe to repeat. Can also be set via the LAMBDA_CLOUD_MAX_RETRIES environment variable. Defaults to 3.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.Between(0, 10),
+				},
+			},
+			"requests_per_second": schema.Float64Attribute{
+				MarkdownDescription: "Maximum rate of API requests, shared by every resource and data source. It is lowered temporarily when the API responds with 429 Too Many Requests. Can also be set via the LAMBDA_CLOUD_REQUESTS_PER_SECOND environment variable. Defaults to 10.",
+				Optional:            true,
+				Validators: []validator.Float64{
+					float64validator.AtLeast(0.1),
+				},
+			},
+			"max_concurrent_requests": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of API requests in flight at once. Can also be set via the LAMBDA_CLOUD_MAX_CONCURRENT_REQUESTS environment variable. Defaults to 8.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"catalog_cache_ttl": schema.StringAttribute{
+				MarkdownDescription: "How long the instance type catalog, with its prices and capacity, is reused before it is fetched again, such as `10m`. Can also be set via the LAMBDA_CLOUD_CATALOG_CACHE_TTL environment variable. Defaults to `5m`.",
+				Optional:            true,
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"catalog_cache_file": schema.StringAttribute{
+				MarkdownDescription: "Path of a file to keep the instance type catalog in between runs. A catalog younger than `catalog_cache_ttl` is read from it rather than fetched. Can also be set via the LAMBDA_CLOUD_CATALOG_CACHE_FILE environment variable.",
+				Optional:            true,
+			},
+			"catalog_snapshot_file": schema.StringAttribute{
+				MarkdownDescription: "Path of a catalog snapshot, written by `terraform-provider-lambda snapshot-catalog`, to serve instance types and images from in place of the API and check planned regions against. Plans that only need the catalog then run without API access or an API key. Can also be set via the LAMBDA_CLOUD_CATALOG_SNAPSHOT_FILE environment variable.",
+				Optional:            true,
+			},
+			"http_proxy": schema.StringAttribute{
+				MarkdownDescription: "URL of the proxy for API requests. Can also be set via the LAMBDA_CLOUD_HTTP_PROXY environment variable. Defaults to the standard HTTPS_PROXY and NO_PROXY environment variables.",
+				Optional:            true,
+			},
+			"ca_cert_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a PEM file of CA certificates to trust in addition to the system ones. Can also be set via the LAMBDA_CLOUD_CA_CERT_FILE environment variable.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("ca_cert_pem")),
+				},
+			},
+			"ca_cert_pem": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded CA certificates to trust in addition to the system ones. Can also be set via the LAMBDA_CLOUD_CA_CERT_PEM environment variable.",
+				Optional:            true,
+			},
+			"insecure_skip_verify": schema.BoolAttribute{
+				MarkdownDescription: "Skip TLS certificate verification. Only allowed with a custom endpoint. Can also be set via the LAMBDA_CLOUD_INSECURE_SKIP_VERIFY environment variable.",
+				Optional:            true,
+			},
+			"allow_insecure_http": schema.BoolAttribute{
+				MarkdownDescription: "Allow an `http://` endpoint, which sends the API key unencrypted. Meant for local test servers. Can also be set via the LAMBDA_CLOUD_ALLOW_INSECURE_HTTP environment variable.",
+				Optional:            true,
+			},
+			"user_agent_suffix": schema.StringAttribute{
+				MarkdownDescription: "Text appended to the User-Agent of API requests, for example to identify a pipeline. Can also be set via the LAMBDA_CLOUD_USER_AGENT_SUFFIX environment variable.",
+				Optional:            true,
+			},
+			"max_hourly_spend_cents": schema.Int64Attribute{
+				MarkdownDescription: "Maximum hourly price, in US cents, that the changes planned in a run may add. Instances that are already running and do not change are not counted. Plans that exceed it fail.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"max_instance_price_cents_per_hour": schema.Int64Attribute{
+				MarkdownDescription: "Maximum hourly price, in US cents, of any single instance. Plans for more expensive instance types fail.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"allowed_regions": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Regions resources may be created in. Defaults to every region.",
+				Optional:            true,
+				Validators: []validator.List{
+					listvalidator.SizeAtLeast(1),
+					listvalidator.ValueStringsAre(stringvalidator.OneOf(lambdaRegions...)),
+				},
+			},
+			"allowed_instance_types": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Glob patterns, such as `gpu_1x_*`, that instance types must match. Defaults to every instance type.",
+				Optional:            true,
+				Validators: []validator.List{
+					listvalidator.SizeAtLeast(1),
+				},
+			},
+			"max_gpus_per_instance": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of GPUs in any single instance, taken from the instance type name.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"name_pattern": schema.StringAttribute{
+				MarkdownDescription: "Regular expression that resource names must match. When set, resources must have a name.",
 				Optional:            true,
 			},
 		},
@@ -69,25 +264,42 @@ func (p *LambdaProvider) Configure(ctx context.Context, req provider.ConfigureRe
 		return
 	}
 
-	// Default values to environment variables, but override with Terraform configuration.
-	apiKey := os.Getenv("LAMBDA_CLOUD_API_KEY")
-	endpoint := "https://cloud.lambda.ai"
+	// Find the API key through the credential chain
+	creds, diags := data.credentials(ctx)
+	resp.Diagnostics.Append(diags...)
+	apiKey := creds.ApiKey
 
-	if !data.ApiKey.IsNull() {
-		apiKey = data.ApiKey.ValueString()
+	// Record or replay API traffic when asked to through the environment
+	var replaying bool
+	cassette := func(next http.RoundTripper) (http.RoundTripper, error) {
+		var transport http.RoundTripper
+		var err error
+		transport, replaying, err = cassetteTransportFromEnv(next)
+		return transport, err
 	}
 
-	if !data.Endpoint.IsNull() {
-		endpoint = data.Endpoint.ValueString()
+	settings, diags := data.httpSettings()
+	resp.Diagnostics.Append(diags...)
+
+	policy, diags := data.policy(ctx)
+	resp.Diagnostics.Append(diags...)
+
+	catalogSettings, diags := data.catalogCacheSettings()
+	resp.Diagnostics.Append(diags...)
+
+	skipCredentialsValidation, err := boolSetting(data.SkipCredentialsValidation, envSkipCredentialsValidation)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("skip_credentials_validation"), "Invalid "+envSkipCredentialsValidation, err.Error())
 	}
 
-	// Validate required configuration
-	if apiKey == "" {
-		resp.Diagnostics.AddError(
-			"Unable to find api_key",
-			"api_key cannot be an empty string. "+
-				"Set the api_key attribute in the provider configuration or use the LAMBDA_CLOUD_API_KEY environment variable.",
-		)
+	// Serve the catalog from a snapshot when planning offline
+	var snapshot *CatalogSnapshot
+	snapshotFile := stringSetting(data.CatalogSnapshotFile, envCatalogSnapshotFile, "")
+	if snapshotFile != "" {
+		snapshot, err = readCatalogSnapshot(snapshotFile)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("catalog_snapshot_file"), "Unable to read catalog snapshot", err.Error())
+		}
 	}
 
 	if resp.Diagnostics.HasError() {
@@ -95,35 +307,161 @@ func (p *LambdaProvider) Configure(ctx context.Context, req provider.ConfigureRe
 	}
 
 	// Create HTTP client with authentication
-	client := &http.Client{}
+	client, err := newHTTPClient(settings, p.userAgent(req.TerraformVersion, settings), cassette)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to create HTTP client",
+			fmt.Sprintf("Unable to set up the HTTP client for the API, got error: %s", err),
+		)
+		return
+	}
+
+	// Replayed responses need no credentials
+	if replaying && apiKey == "" {
+		apiKey = redacted
+	}
+
+	// Validate required configuration. Offline plans from a snapshot need no
+	// credentials.
+	if apiKey == "" && snapshot == nil {
+		resp.Diagnostics.AddError(
+			"Unable to find api_key",
+			"api_key cannot be an empty string. "+
+				"Set the api_key attribute in the provider configuration or use the LAMBDA_CLOUD_API_KEY environment variable, "+
+				"or configure api_key_file, profile or credential_process.",
+		)
+		return
+	}
+
+	tflog.Debug(ctx, "Using API key", map[string]interface{}{"source": creds.Source})
+
+	// Keys from a credential process expire and are fetched again
+	if creds.process != nil {
+		client.Transport = &credentialProcessTransport{next: client.Transport, process: creds.process}
+	}
 
 	// Create provider configuration
 	config := &ProviderConfig{
-		ApiKey:     apiKey,
-		Endpoint:   endpoint,
-		HTTPClient: client,
+		ApiKey:                       apiKey,
+		Endpoint:                     settings.Endpoint,
+		HTTPClient:                   client,
+		MaxHourlySpendCents:          data.MaxHourlySpendCents.ValueInt64(),
+		MaxInstancePriceCentsPerHour: data.MaxInstancePriceCentsPerHour.ValueInt64(),
+		Policy:                       policy,
+		spend:                        &spendTracker{},
+	}
+	config.launches = newLaunchBatcher(defaultLaunchBatchWindow, config)
+	config.instances = newInstanceSnapshot(defaultInstanceSnapshotTTL, config.ListInstances)
+	config.catalog = newCatalogCache(catalogSettings, settings.Endpoint, config.ListInstanceTypes)
+
+	if snapshot != nil {
+		config.snapshot = snapshot
+		resp.Diagnostics.AddAttributeWarning(path.Root("catalog_snapshot_file"), "Using catalog snapshot", snapshotWarning(snapshotFile, snapshot, time.Now()))
+	}
+
+	// Check the API key once, up front. Replayed responses say nothing
+	// about it, and a snapshot is used where the API is out of reach.
+	if !skipCredentialsValidation && !replaying && snapshot == nil {
+		resp.Diagnostics.Append(config.validateCredentials(ctx, creds.Source)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
 	}
 
 	// Make the configuration available to resources and data sources
 	resp.DataSourceData = config
 	resp.ResourceData = config
+	resp.ActionData = config
+	resp.EphemeralResourceData = config
+}
+
+// userAgent returns the User-Agent sent with API requests, which identifies
+// the provider and the Terraform version driving it.
+func (p *LambdaProvider) userAgent(terraformVersion string, settings httpSettings) string {
+	if terraformVersion == "" {
+		terraformVersion = "unknown"
+	}
+
+	userAgent := fmt.Sprintf("terraform-provider-lambda/%s (+terraform %s)", p.version, terraformVersion)
+	if settings.UserAgentSuffix != "" {
+		userAgent += " " + settings.UserAgentSuffix
+	}
+	return userAgent
+}
+
+// policy validates and returns the policy settings of the provider block.
+func (m *LambdaProviderModel) policy(ctx context.Context) (Policy, diag.Diagnostics) {
+	var policy Policy
+	var diags diag.Diagnostics
+
+	if !m.AllowedRegions.IsNull() {
+		diags.Append(m.AllowedRegions.ElementsAs(ctx, &policy.AllowedRegions, false)...)
+	}
+
+	if !m.AllowedInstanceTypes.IsNull() {
+		diags.Append(m.AllowedInstanceTypes.ElementsAs(ctx, &policy.AllowedInstanceTypes, false)...)
+		for i, pattern := range policy.AllowedInstanceTypes {
+			if _, err := pathpkg.Match(pattern, ""); err != nil {
+				diags.AddAttributeError(
+					path.Root("allowed_instance_types").AtListIndex(i),
+					"Invalid instance type pattern",
+					fmt.Sprintf("%q is not a valid glob pattern: %s", pattern, err),
+				)
+			}
+		}
+	}
+
+	policy.MaxGpusPerInstance = m.MaxGpusPerInstance.ValueInt64()
+
+	if !m.NamePattern.IsNull() {
+		pattern, err := regexp.Compile(m.NamePattern.ValueString())
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root("name_pattern"),
+				"Invalid name pattern",
+				fmt.Sprintf("name_pattern is not a valid regular expression: %s", err),
+			)
+		}
+		policy.NamePattern = pattern
+	}
+
+	return policy, diags
 }
 
 func (p *LambdaProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewInstanceResource,
+		NewInstanceGroupResource,
 		NewSshKeyResource,
 	}
 }
 
+func (p *LambdaProvider) EphemeralResources(ctx context.Context) []func() ephemeral.EphemeralResource {
+	return []func() ephemeral.EphemeralResource{
+		NewSshKeyPairEphemeralResource,
+	}
+}
+
 func (p *LambdaProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
 		NewInstanceTypesDataSource,
+		NewImagesDataSource,
+	}
+}
+
+func (p *LambdaProvider) Actions(ctx context.Context) []func() action.Action {
+	return []func() action.Action{
+		NewInstanceRestartAction,
+		NewInstanceColdRebootAction,
 	}
 }
 
 func (p *LambdaProvider) Functions(ctx context.Context) []func() function.Function {
-	return []func() function.Function{}
+	return []func() function.Function{
+		NewParseInstanceTypeFunction,
+		NewCompareInstanceTypesFunction,
+		NewCloudInitFunction,
+	}
 }
 
 func New(version string) func() provider.Provider {
@@ -0,0 +1,170 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-testing/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+
+	"github.com/albertocavalcante/terraform-provider-lambda/internal/fakelambda"
+)
+
+const (
+	// testAccRealAPIEnv opts acceptance tests into running against the real
+	// Lambda Cloud API, which launches billed instances. By default they run
+	// against the in-memory API stand-in.
+	testAccRealAPIEnv = "LAMBDA_ACC_REAL_API"
+
+	// testAccResourcePrefix starts the name of everything acceptance tests
+	// create, so that the sweepers can find leaked resources.
+	testAccResourcePrefix = "tf-acc-"
+)
+
+// testAccProtoV6ProviderFactories is used to instantiate a provider during
+// acceptance testing. The factory function is called for each Terraform CLI
+// command to create a provider server that the CLI can connect to and
+// interact with.
+var testAccProtoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServer, error){
+	"lambda": providerserver.NewProtocol6WithError(New("test")()),
+}
+
+func TestMain(m *testing.M) {
+	resource.TestMain(m)
+}
+
+// testAccEnv is where an acceptance test runs: the real API, or a fresh API
+// stand-in.
+type testAccEnv struct {
+	// Fake is nil when running against the real API.
+	Fake *fakelambda.Server
+
+	ApiKey   string
+	Endpoint string
+
+	// SshKeyName, InstanceTypeName and RegionName are what test instances
+	// are launched with.
+	SshKeyName       string
+	InstanceTypeName string
+	RegionName       string
+}
+
+// testAccPreCheck returns the environment for an acceptance test, starting an
+// API stand-in unless LAMBDA_ACC_REAL_API is set. Against the real API it
+// requires LAMBDA_CLOUD_API_KEY and LAMBDA_ACC_SSH_KEY_NAME, and uses
+// LAMBDA_CLOUD_ENDPOINT, LAMBDA_ACC_INSTANCE_TYPE and LAMBDA_ACC_REGION when
+// set.
+func testAccPreCheck(t *testing.T) *testAccEnv {
+	t.Helper()
+
+	if os.Getenv(resource.EnvTfAcc) == "" {
+		t.Skipf("Acceptance tests skipped unless env '%s' set", resource.EnvTfAcc)
+	}
+
+	if os.Getenv(testAccRealAPIEnv) == "" {
+		fake := fakelambda.New(fakelambda.Options{APIKey: "tf-acc-api-key"})
+		server := httptest.NewServer(fake)
+		t.Cleanup(server.Close)
+
+		sshKeyName := testAccResourcePrefix + acctest.RandString(8)
+		if _, err := fake.AddSshKey(sshKeyName); err != nil {
+			t.Fatal(err)
+		}
+
+		return &testAccEnv{
+			Fake:             fake,
+			ApiKey:           "tf-acc-api-key",
+			Endpoint:         server.URL,
+			SshKeyName:       sshKeyName,
+			InstanceTypeName: "gpu_1x_a10",
+			RegionName:       "us-east-1",
+		}
+	}
+
+	env := &testAccEnv{
+		ApiKey:           os.Getenv("LAMBDA_CLOUD_API_KEY"),
+		Endpoint:         envOrDefault(envEndpoint, defaultEndpoint),
+		SshKeyName:       os.Getenv("LAMBDA_ACC_SSH_KEY_NAME"),
+		InstanceTypeName: envOrDefault("LAMBDA_ACC_INSTANCE_TYPE", "gpu_1x_a10"),
+		RegionName:       envOrDefault("LAMBDA_ACC_REGION", "us-east-1"),
+	}
+
+	if env.ApiKey == "" {
+		t.Fatal("LAMBDA_CLOUD_API_KEY must be set for acceptance tests against the real API")
+	}
+	if env.SshKeyName == "" {
+		t.Fatal("LAMBDA_ACC_SSH_KEY_NAME must be set for acceptance tests against the real API")
+	}
+
+	return env
+}
+
+// skipUnlessFake skips tests that need to tamper with the API's state.
+func (e *testAccEnv) skipUnlessFake(t *testing.T) {
+	t.Helper()
+
+	if e.Fake == nil {
+		t.Skip("Test requires the API stand-in")
+	}
+}
+
+// providerConfig returns the provider block for test configurations.
+func (e *testAccEnv) providerConfig() string {
+	if e.Fake == nil {
+		return ""
+	}
+
+	return fmt.Sprintf(`
+provider "lambda" {
+  api_key             = %q
+  endpoint            = %q
+  allow_insecure_http = true
+}
+`, e.ApiKey, e.Endpoint)
+}
+
+// client returns an API client for checking results outside Terraform.
+func (e *testAccEnv) client() *ProviderConfig {
+	return &ProviderConfig{
+		ApiKey:     e.ApiKey,
+		Endpoint:   e.Endpoint,
+		HTTPClient: &http.Client{},
+		spend:      &spendTracker{},
+	}
+}
+
+func envOrDefault(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func TestAccProvider_invalidAPIKey(t *testing.T) {
+	env := testAccPreCheck(t)
+	env.skipUnlessFake(t)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: fmt.Sprintf(`
+provider "lambda" {
+  api_key             = "not-the-key"
+  endpoint            = %q
+  allow_insecure_http = true
+}
+
+data "lambda_instance_types" "test" {}
+`, env.Endpoint),
+				ExpectError: regexp.MustCompile(`Invalid API key`),
+			},
+		},
+	})
+}
@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultRequestsPerSecond     = 10
+	defaultMaxConcurrentRequests = 8
+
+	// rateLimitFloorDivisor bounds how far 429s can slow the limiter down,
+	// as a fraction of the configured rate.
+	rateLimitFloorDivisor = 16
+
+	// rateLimitRecoveryDivisor sets how much each successful request
+	// restores of the configured rate after a slowdown.
+	rateLimitRecoveryDivisor = 20
+
+	// rateLimitCooldown is the minimum time between two slowdowns, so that
+	// a burst of 429s from requests already in flight counts once.
+	rateLimitCooldown = time.Second
+)
+
+// rateLimitTransport paces API calls with a token bucket and caps how many are
+// in flight. It sits in the client shared by every resource and data source,
+// so the limits hold across a parallel apply. When the API answers 429 the
+// rate is halved, and it recovers gradually as requests succeed.
+type rateLimitTransport struct {
+	next     http.RoundTripper
+	limiter  *rate.Limiter
+	inFlight chan struct{}
+	now      func() time.Time
+
+	maxRate rate.Limit
+	minRate rate.Limit
+
+	mu           sync.Mutex
+	lastSlowdown time.Time
+}
+
+func newRateLimitTransport(next http.RoundTripper, requestsPerSecond float64, maxConcurrentRequests int) *rateLimitTransport {
+	maxRate := rate.Limit(requestsPerSecond)
+
+	return &rateLimitTransport{
+		next:     next,
+		limiter:  rate.NewLimiter(maxRate, int(math.Max(1, math.Ceil(requestsPerSecond)))),
+		inFlight: make(chan struct{}, maxConcurrentRequests),
+		now:      time.Now,
+		maxRate:  maxRate,
+		minRate:  maxRate / rateLimitFloorDivisor,
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	select {
+	case t.inFlight <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-t.inFlight }()
+
+	if err := t.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		t.slowDown(ctx)
+	} else if resp.StatusCode < http.StatusBadRequest {
+		t.speedUp()
+	}
+
+	return resp, nil
+}
+
+// slowDown halves the rate after a 429, down to the floor.
+func (t *rateLimitTransport) slowDown(ctx context.Context) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if now.Sub(t.lastSlowdown) < rateLimitCooldown {
+		return
+	}
+	t.lastSlowdown = now
+
+	limit := t.limiter.Limit() / 2
+	if limit < t.minRate {
+		limit = t.minRate
+	}
+	t.limiter.SetLimitAt(now, limit)
+
+	tflog.Warn(ctx, "API is rate limiting requests, slowing down", map[string]interface{}{
+		"requests_per_second": float64(limit),
+	})
+}
+
+// speedUp restores part of the configured rate after a successful request.
+func (t *rateLimitTransport) speedUp() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	limit := t.limiter.Limit()
+	if limit >= t.maxRate {
+		return
+	}
+
+	limit += t.maxRate / rateLimitRecoveryDivisor
+	if limit > t.maxRate {
+		limit = t.maxRate
+	}
+	t.limiter.SetLimitAt(t.now(), limit)
+}
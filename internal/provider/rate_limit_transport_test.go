@@ -0,0 +1,108 @@
+package provider
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func testResponse(statusCode int) *http.Response {
+	return &http.Response{StatusCode: statusCode, Body: io.NopCloser(strings.NewReader(""))}
+}
+
+func TestRateLimitTransportMaxInFlight(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	release := make(chan struct{})
+
+	transport := newRateLimitTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			seen := maxInFlight.Load()
+			if n <= seen || maxInFlight.CompareAndSwap(seen, n) {
+				break
+			}
+		}
+		<-release
+		return testResponse(http.StatusOK), nil
+	}), 1000, 3)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest(http.MethodGet, "http://lambda.test/api/v1/instances", nil)
+			if _, err := transport.RoundTrip(req); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	// Let the first requests pile up against the cap before releasing them
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := maxInFlight.Load(); got != 3 {
+		t.Errorf("expected at most 3 requests in flight, got %d", got)
+	}
+}
+
+func TestRateLimitTransportAdapts(t *testing.T) {
+	statusCode := http.StatusTooManyRequests
+	transport := newRateLimitTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return testResponse(statusCode), nil
+	}), 1000, 8)
+
+	now := time.Now()
+	transport.now = func() time.Time { return now }
+
+	send := func() {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, "http://lambda.test/api/v1/instances", nil)
+		if _, err := transport.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	send()
+	if got := transport.limiter.Limit(); got != 500 {
+		t.Fatalf("expected the rate to halve after a 429, got %v", got)
+	}
+
+	// 429s from requests already in flight count once
+	send()
+	if got := transport.limiter.Limit(); got != 500 {
+		t.Fatalf("expected a single slowdown within the cooldown, got %v", got)
+	}
+
+	for i := 0; i < 10; i++ {
+		now = now.Add(rateLimitCooldown)
+		send()
+	}
+	if got, want := transport.limiter.Limit(), rate.Limit(1000.0/rateLimitFloorDivisor); got != want {
+		t.Fatalf("expected the rate to stop at %v, got %v", want, got)
+	}
+
+	statusCode = http.StatusOK
+	for i := 0; i < rateLimitRecoveryDivisor; i++ {
+		send()
+	}
+	if got := transport.limiter.Limit(); got != 1000 {
+		t.Errorf("expected the rate to recover to 1000, got %v", got)
+	}
+}
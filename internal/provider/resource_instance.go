@@ -2,26 +2,44 @@ package provider
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"net/http"
+	"math"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/setplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &InstanceResource{}
 var _ resource.ResourceWithImportState = &InstanceResource{}
+var _ resource.ResourceWithConfigValidators = &InstanceResource{}
+var _ resource.ResourceWithModifyPlan = &InstanceResource{}
+var _ resource.ResourceWithUpgradeState = &InstanceResource{}
+
+// lambdaRegions lists the Lambda Cloud region codes accepted by the provider.
+var lambdaRegions = []string{
+	"europe-central-1", "asia-south-1", "australia-east-1",
+	"me-west-1", "asia-northeast-1", "asia-northeast-2",
+	"us-east-1", "us-west-2", "us-west-1", "us-south-1",
+	"us-west-3", "us-midwest-1", "us-east-2", "us-south-2",
+	"us-south-3", "us-east-3", "us-midwest-2",
+	"test-east-1", "test-west-1",
+}
 
 func NewInstanceResource() resource.Resource {
 	return &InstanceResource{}
@@ -40,6 +58,7 @@ func (r *InstanceResource) Schema(ctx context.Context, req resource.SchemaReques
 	// Use the generated schema from the codegen
 	// We'll need to import the generated package and use its schema
 	resp.Schema = schema.Schema{
+		Version:             instanceSchemaVersion,
 		MarkdownDescription: "Manages a Lambda Cloud GPU instance.",
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
@@ -51,49 +70,106 @@ func (r *InstanceResource) Schema(ctx context.Context, req resource.SchemaReques
 			},
 			"name": schema.StringAttribute{
 				Optional:            true,
-				MarkdownDescription: "User-provided name for the instance (max 64 chars)",
+				MarkdownDescription: "User-provided name for the instance (max 64 chars). Changing it renames the instance in place",
 				Validators: []validator.String{
 					stringvalidator.LengthBetween(0, 64),
 				},
 			},
 			"region_name": schema.StringAttribute{
-				Required:            true,
-				MarkdownDescription: "Lambda Cloud region code where instance will be launched",
+				Optional:            true,
+				Computed:            true,
+				MarkdownDescription: "Lambda Cloud region code where instance will be launched. When `launch_candidates` is used, this is the region the instance was actually launched in.",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.RequiresReplaceIfConfigured(),
 				},
 				Validators: []validator.String{
-					stringvalidator.OneOf(
-						"europe-central-1", "asia-south-1", "australia-east-1",
-						"me-west-1", "asia-northeast-1", "asia-northeast-2",
-						"us-east-1", "us-west-2", "us-west-1", "us-south-1",
-						"us-west-3", "us-midwest-1", "us-east-2", "us-south-2",
-						"us-south-3", "us-east-3", "us-midwest-2",
-						"test-east-1", "test-west-1",
-					),
+					stringvalidator.OneOf(lambdaRegions...),
 				},
 			},
 			"instance_type_name": schema.StringAttribute{
-				Required:            true,
-				MarkdownDescription: "Name of the instance type to launch",
+				Optional:            true,
+				Computed:            true,
+				MarkdownDescription: "Name of the instance type to launch. When `launch_candidates` is used, this is the instance type that was actually launched.",
 				PlanModifiers: []planmodifier.String{
-					stringplanmodifier.RequiresReplace(),
+					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.RequiresReplaceIfConfigured(),
+				},
+			},
+			"launch_candidates": schema.ListNestedAttribute{
+				Optional: true,
+				MarkdownDescription: "Ordered list of instance type and region pairs to try at launch. " +
+					"Each candidate is tried in turn while the API reports insufficient capacity, and the pair that launched is recorded in `instance_type_name` and `region_name`. " +
+					"Conflicts with setting `instance_type_name` and `region_name` directly.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"instance_type_name": schema.StringAttribute{
+							Required:            true,
+							MarkdownDescription: "Name of the instance type to try",
+						},
+						"region_name": schema.StringAttribute{
+							Required:            true,
+							MarkdownDescription: "Lambda Cloud region code to try",
+							Validators: []validator.String{
+								stringvalidator.OneOf(lambdaRegions...),
+							},
+						},
+					},
+				},
+				PlanModifiers: []planmodifier.List{
+					listplanmodifier.RequiresReplaceIf(
+						launchCandidatesRequireReplace,
+						"Requires replacement if the launched instance type and region are no longer a candidate.",
+						"Requires replacement if the launched instance type and region are no longer a candidate.",
+					),
+				},
+				Validators: []validator.List{
+					listvalidator.SizeAtLeast(1),
+				},
+			},
+			"wait_for_capacity": schema.SingleNestedAttribute{
+				Optional: true,
+				MarkdownDescription: "Keep retrying the launch when no launch candidate has capacity. " +
+					"The provider polls the instance types API until a candidate region reports capacity, then launches. " +
+					"Without this block, the apply fails as soon as every candidate reports insufficient capacity.",
+				Attributes: map[string]schema.Attribute{
+					"timeout": schema.StringAttribute{
+						Required:            true,
+						MarkdownDescription: "How long to wait for capacity before failing, as a duration such as `2h`",
+						Validators: []validator.String{
+							durationValidator{},
+						},
+					},
+					"poll_interval": schema.StringAttribute{
+						Optional:            true,
+						MarkdownDescription: fmt.Sprintf("How often to poll for capacity, as a duration. Defaults to `%s`", defaultCapacityPollInterval),
+						Validators: []validator.String{
+							durationValidator{},
+						},
+					},
 				},
 			},
-			"ssh_key_names": schema.ListAttribute{
+			"ssh_key_names": schema.SetAttribute{
 				ElementType:         types.StringType,
 				Required:            true,
-				MarkdownDescription: "List of SSH key names to add to the instance",
-				PlanModifiers: []planmodifier.List{
-					listplanmodifier.RequiresReplace(),
+				MarkdownDescription: "Set of SSH key names to add to the instance. Their order does not matter",
+				PlanModifiers: []planmodifier.Set{
+					setplanmodifier.RequiresReplace(),
 				},
 			},
-			"file_system_names": schema.ListAttribute{
+			"file_system_names": schema.SetAttribute{
 				ElementType:         types.StringType,
 				Optional:            true,
-				MarkdownDescription: "List of filesystem names to mount to the instance",
-				PlanModifiers: []planmodifier.List{
-					listplanmodifier.RequiresReplace(),
+				MarkdownDescription: "Set of filesystem names to mount to the instance. Their order does not matter",
+				PlanModifiers: []planmodifier.Set{
+					setplanmodifier.RequiresReplace(),
+				},
+			},
+			"user_data": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "cloud-init user data to run on first boot. See the `cloud_init` provider function",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
 				},
 			},
 			"ip": schema.StringAttribute{
@@ -112,6 +188,21 @@ func (r *InstanceResource) Schema(ctx context.Context, req resource.SchemaReques
 				Computed:            true,
 				MarkdownDescription: "The current status of the instance",
 			},
+			"price_cents_per_hour": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "Price of the instance in US cents per hour",
+			},
+			"launched_at": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "When the instance was launched, as an RFC 3339 timestamp. Null for imported instances",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"estimated_cost_cents": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "Estimated cost of the instance since `launched_at`, in US cents, as of the last refresh",
+			},
 		},
 	}
 }
@@ -136,49 +227,188 @@ func (r *InstanceResource) Configure(ctx context.Context, req resource.Configure
 	r.client = client
 }
 
+func (r *InstanceResource) ConfigValidators(ctx context.Context) []resource.ConfigValidator {
+	return []resource.ConfigValidator{
+		resourcevalidator.ExactlyOneOf(
+			path.MatchRoot("instance_type_name"),
+			path.MatchRoot("launch_candidates"),
+		),
+		resourcevalidator.RequiredTogether(
+			path.MatchRoot("instance_type_name"),
+			path.MatchRoot("region_name"),
+		),
+	}
+}
+
+func (r *InstanceResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check when destroying, or before the provider is configured
+	if req.Plan.Raw.IsNull() || r.client == nil {
+		return
+	}
+
+	var plan InstanceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var state *InstanceModel
+	if !req.State.Raw.IsNull() {
+		state = &InstanceModel{}
+		resp.Diagnostics.Append(req.State.Get(ctx, state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	resp.Diagnostics.Append(r.checkPolicy(ctx, &plan, state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	planned := plannedSpend{Count: 1, Path: path.Root("instance_type_name")}
+
+	switch {
+	case !plan.InstanceTypeName.IsUnknown():
+		planned.InstanceTypeNames = knownStrings(plan.InstanceTypeName)
+	case !plan.LaunchCandidates.IsUnknown():
+		var candidates []LaunchCandidateModel
+		resp.Diagnostics.Append(plan.LaunchCandidates.ElementsAs(ctx, &candidates, false)...)
+		for _, candidate := range candidates {
+			planned.InstanceTypeNames = append(planned.InstanceTypeNames, knownStrings(candidate.InstanceTypeName)...)
+		}
+		planned.Path = path.Root("launch_candidates")
+	}
+
+	// The instance type is not known until apply
+	if len(planned.InstanceTypeNames) == 0 || resp.Diagnostics.HasError() {
+		return
+	}
+
+	// An instance that does not change adds no spend
+	if state != nil && req.Plan.Raw.Equal(req.State.Raw) {
+		return
+	}
+
+	if state != nil {
+		planned.PriorInstanceTypeName = state.InstanceTypeName.ValueString()
+		planned.PriorCount = 1
+	}
+
+	resp.Diagnostics.Append(r.client.checkSpend(ctx, planned)...)
+}
+
+// checkPolicy enforces the provider's allowlists. Only values that change are
+// checked, so instances that predate a policy can still be planned.
+func (r *InstanceResource) checkPolicy(ctx context.Context, plan, state *InstanceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	policy := &r.client.Policy
+
+	if state == nil || !plan.Name.Equal(state.Name) {
+		diags.Append(policy.checkName(path.Root("name"), plan.Name)...)
+	}
+
+	if changedKnownString(plan.RegionName, state, func(m *InstanceModel) types.String { return m.RegionName }) {
+		diags.Append(r.client.checkRegion(path.Root("region_name"), plan.RegionName.ValueString())...)
+	}
+
+	if changedKnownString(plan.InstanceTypeName, state, func(m *InstanceModel) types.String { return m.InstanceTypeName }) {
+		diags.Append(policy.checkInstanceType(path.Root("instance_type_name"), plan.InstanceTypeName.ValueString())...)
+	}
+
+	if plan.LaunchCandidates.IsNull() || plan.LaunchCandidates.IsUnknown() ||
+		(state != nil && plan.LaunchCandidates.Equal(state.LaunchCandidates)) {
+		return diags
+	}
+
+	var candidates []LaunchCandidateModel
+	diags.Append(plan.LaunchCandidates.ElementsAs(ctx, &candidates, false)...)
+
+	for i, candidate := range candidates {
+		candidatePath := path.Root("launch_candidates").AtListIndex(i)
+		if !candidate.RegionName.IsUnknown() {
+			diags.Append(r.client.checkRegion(candidatePath.AtName("region_name"), candidate.RegionName.ValueString())...)
+		}
+		if !candidate.InstanceTypeName.IsUnknown() {
+			diags.Append(policy.checkInstanceType(candidatePath.AtName("instance_type_name"), candidate.InstanceTypeName.ValueString())...)
+		}
+	}
+
+	return diags
+}
+
+// changedKnownString reports whether a planned value is known, set and
+// different from the prior state. Without prior state every set value counts
+// as changed.
+func changedKnownString[M any](planned types.String, state *M, prior func(*M) types.String) bool {
+	if planned.IsNull() || planned.IsUnknown() {
+		return false
+	}
+	return state == nil || !planned.Equal(prior(state))
+}
+
+// launchCandidatesRequireReplace replaces the instance only when the instance
+// type and region it was launched with drop out of the candidate list.
+// Reordering candidates, or an earlier candidate regaining capacity, must not
+// relaunch an instance that is already running.
+func launchCandidatesRequireReplace(ctx context.Context, req planmodifier.ListRequest, resp *listplanmodifier.RequiresReplaceIfFuncResponse) {
+	if req.State.Raw.IsNull() || req.PlanValue.IsNull() || req.PlanValue.IsUnknown() {
+		return
+	}
+
+	var regionName, instanceTypeName types.String
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("region_name"), &regionName)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("instance_type_name"), &instanceTypeName)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var candidates []LaunchCandidateModel
+	resp.Diagnostics.Append(req.PlanValue.ElementsAs(ctx, &candidates, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	for _, candidate := range candidates {
+		if candidate.RegionName.Equal(regionName) && candidate.InstanceTypeName.Equal(instanceTypeName) {
+			return
+		}
+	}
+
+	resp.RequiresReplace = true
+}
+
 // InstanceModel describes the resource data model.
 type InstanceModel struct {
 	Id               types.String `tfsdk:"id"`
 	Name             types.String `tfsdk:"name"`
 	RegionName       types.String `tfsdk:"region_name"`
 	InstanceTypeName types.String `tfsdk:"instance_type_name"`
-	SshKeyNames      types.List   `tfsdk:"ssh_key_names"`
-	FileSystemNames  types.List   `tfsdk:"file_system_names"`
+	LaunchCandidates types.List   `tfsdk:"launch_candidates"`
+	WaitForCapacity  types.Object `tfsdk:"wait_for_capacity"`
+	SshKeyNames      types.Set    `tfsdk:"ssh_key_names"`
+	FileSystemNames  types.Set    `tfsdk:"file_system_names"`
+	UserData         types.String `tfsdk:"user_data"`
 	Ip               types.String `tfsdk:"ip"`
 	PrivateIp        types.String `tfsdk:"private_ip"`
 	Hostname         types.String `tfsdk:"hostname"`
 	Status           types.String `tfsdk:"status"`
-}
-
-// LaunchRequest represents the request body for launching instances
-type LaunchRequest struct {
-	RegionName       string   `json:"region_name"`
-	InstanceTypeName string   `json:"instance_type_name"`
-	SshKeyNames      []string `json:"ssh_key_names"`
-	Name             *string  `json:"name,omitempty"`
-	FileSystemNames  []string `json:"file_system_names,omitempty"`
-}
 
-// LaunchResponse represents the response from the launch API
-type LaunchResponse struct {
-	Data struct {
-		InstanceIds []string `json:"instance_ids"`
-	} `json:"data"`
+	PriceCentsPerHour  types.Int64  `tfsdk:"price_cents_per_hour"`
+	LaunchedAt         types.String `tfsdk:"launched_at"`
+	EstimatedCostCents types.Int64  `tfsdk:"estimated_cost_cents"`
 }
 
-// Instance represents an instance from the read API
-type Instance struct {
-	Id        string `json:"id"`
-	Name      string `json:"name"`
-	Ip        string `json:"ip"`
-	PrivateIp string `json:"private_ip"`
-	Hostname  string `json:"hostname"`
-	Status    string `json:"status"`
+// LaunchCandidateModel describes one entry of launch_candidates.
+type LaunchCandidateModel struct {
+	InstanceTypeName types.String `tfsdk:"instance_type_name"`
+	RegionName       types.String `tfsdk:"region_name"`
 }
 
-// InstanceResponse represents the response from the read API
-type InstanceResponse struct {
-	Data Instance `json:"data"`
+// WaitForCapacityModel describes the wait_for_capacity block.
+type WaitForCapacityModel struct {
+	Timeout      types.String `tfsdk:"timeout"`
+	PollInterval types.String `tfsdk:"poll_interval"`
 }
 
 func (r *InstanceResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
@@ -205,12 +435,17 @@ func (r *InstanceResource) Create(ctx context.Context, req resource.CreateReques
 		}
 	}
 
+	candidates, diags := data.launchCandidates(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Create launch request
 	launchReq := LaunchRequest{
-		RegionName:       data.RegionName.ValueString(),
-		InstanceTypeName: data.InstanceTypeName.ValueString(),
-		SshKeyNames:      sshKeyNames,
-		FileSystemNames:  fileSystemNames,
+		SshKeyNames:     sshKeyNames,
+		FileSystemNames: fileSystemNames,
+		UserData:        data.UserData.ValueString(),
 	}
 
 	if !data.Name.IsNull() {
@@ -218,13 +453,24 @@ func (r *InstanceResource) Create(ctx context.Context, req resource.CreateReques
 		launchReq.Name = &name
 	}
 
-	// Make launch API call
-	instanceId, err := r.launchInstance(ctx, launchReq)
+	wait, diags := data.capacityWait(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Make launch API call, falling back through the candidates on capacity errors
+	instanceId, launched, err := r.launchWithCapacityWait(ctx, launchReq, candidates, wait)
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create instance, got error: %s", err))
 		return
 	}
 
+	// Record the candidate that was actually launched
+	data.RegionName = types.StringValue(launched.RegionName)
+	data.InstanceTypeName = types.StringValue(launched.InstanceTypeName)
+	data.LaunchedAt = types.StringValue(time.Now().UTC().Format(time.RFC3339))
+
 	// Set the instance ID
 	data.Id = types.StringValue(instanceId)
 
@@ -232,7 +478,9 @@ func (r *InstanceResource) Create(ctx context.Context, req resource.CreateReques
 	err = r.readInstance(ctx, &data)
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read instance after creation, got error: %s", err))
-		return
+
+		// Still record the ID so the launched instance is not orphaned
+		data.nullUnknownComputed()
 	}
 
 	// Write logs using the tflog package
@@ -253,6 +501,12 @@ func (r *InstanceResource) Read(ctx context.Context, req resource.ReadRequest, r
 
 	// Read the instance
 	err := r.readInstance(ctx, &data)
+	if isNotFound(err) {
+		// The instance was terminated outside Terraform
+		tflog.Warn(ctx, "Instance no longer exists, removing it from state", map[string]interface{}{"instance_id": data.Id.ValueString()})
+		resp.State.RemoveResource(ctx)
+		return
+	}
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read instance, got error: %s", err))
 		return
@@ -263,11 +517,32 @@ func (r *InstanceResource) Read(ctx context.Context, req resource.ReadRequest, r
 }
 
 func (r *InstanceResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	// Lambda instances don't support update operations - all changes require replacement
-	resp.Diagnostics.AddError(
-		"Update not supported",
-		"Instance updates are not supported. All changes require resource replacement.",
-	)
+	var plan, state InstanceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The name is the only instance setting that changes in place. Other
+	// in-place changes are provider-side launch settings, such as reordered
+	// launch_candidates.
+	if !plan.Name.Equal(state.Name) {
+		if err := r.client.RenameInstance(ctx, state.Id.ValueString(), plan.Name.ValueString()); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to rename instance, got error: %s", err))
+			return
+		}
+	}
+
+	plan.Ip = state.Ip
+	plan.PrivateIp = state.PrivateIp
+	plan.Hostname = state.Hostname
+	plan.Status = state.Status
+	plan.PriceCentsPerHour = state.PriceCentsPerHour
+	plan.EstimatedCostCents = state.EstimatedCostCents
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
 }
 
 func (r *InstanceResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
@@ -281,7 +556,7 @@ func (r *InstanceResource) Delete(ctx context.Context, req resource.DeleteReques
 
 	// Terminate the instance
 	err := r.terminateInstance(ctx, data.Id.ValueString())
-	if err != nil {
+	if err != nil && !isNotFound(err) {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete instance, got error: %s", err))
 		return
 	}
@@ -291,119 +566,257 @@ func (r *InstanceResource) ImportState(ctx context.Context, req resource.ImportS
 	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
 }
 
-// Helper methods for API calls
+// launchCandidates returns the instance type and region pairs to try, in
+// order. Without launch_candidates the configured pair is the only candidate.
+func (m *InstanceModel) launchCandidates(ctx context.Context) ([]LaunchCandidate, diag.Diagnostics) {
+	if m.LaunchCandidates.IsNull() {
+		return []LaunchCandidate{{
+			InstanceTypeName: m.InstanceTypeName.ValueString(),
+			RegionName:       m.RegionName.ValueString(),
+		}}, nil
+	}
 
-func (r *InstanceResource) launchInstance(ctx context.Context, launchReq LaunchRequest) (string, error) {
-	jsonData, err := json.Marshal(launchReq)
-	if err != nil {
-		return "", err
+	var models []LaunchCandidateModel
+	diags := m.LaunchCandidates.ElementsAs(ctx, &models, false)
+	if diags.HasError() {
+		return nil, diags
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST",
-		fmt.Sprintf("%s/api/v1/instance-operations/launch", r.client.Endpoint),
-		strings.NewReader(string(jsonData)))
-	if err != nil {
-		return "", err
+	candidates := make([]LaunchCandidate, 0, len(models))
+	for _, model := range models {
+		candidates = append(candidates, LaunchCandidate{
+			InstanceTypeName: model.InstanceTypeName.ValueString(),
+			RegionName:       model.RegionName.ValueString(),
+		})
 	}
 
-	r.client.AddAuthHeader(httpReq)
+	return candidates, diags
+}
 
-	httpResp, err := r.client.HTTPClient.Do(httpReq)
-	if err != nil {
-		return "", err
+// capacityWait returns the wait_for_capacity settings, or nil when the block
+// is not set.
+func (m *InstanceModel) capacityWait(ctx context.Context) (*capacityWait, diag.Diagnostics) {
+	if m.WaitForCapacity.IsNull() || m.WaitForCapacity.IsUnknown() {
+		return nil, nil
 	}
-	defer func() {
-		if err := httpResp.Body.Close(); err != nil {
-			tflog.Warn(ctx, "Failed to close response body", map[string]interface{}{"error": err})
-		}
-	}()
 
-	if httpResp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("launch API returned status %d", httpResp.StatusCode)
+	var model WaitForCapacityModel
+	diags := m.WaitForCapacity.As(ctx, &model, basetypes.ObjectAsOptions{})
+	if diags.HasError() {
+		return nil, diags
 	}
 
-	var launchResp LaunchResponse
-	if err := json.NewDecoder(httpResp.Body).Decode(&launchResp); err != nil {
-		return "", err
+	// Both values have already been checked by durationValidator
+	wait := &capacityWait{PollInterval: defaultCapacityPollInterval}
+	wait.Timeout, _ = time.ParseDuration(model.Timeout.ValueString())
+	if !model.PollInterval.IsNull() {
+		wait.PollInterval, _ = time.ParseDuration(model.PollInterval.ValueString())
 	}
 
-	if len(launchResp.Data.InstanceIds) == 0 {
-		return "", fmt.Errorf("no instance IDs returned from launch API")
-	}
+	return wait, diags
+}
+
+// defaultCapacityPollInterval is how often wait_for_capacity polls by default.
+const defaultCapacityPollInterval = 30 * time.Second
 
-	return launchResp.Data.InstanceIds[0], nil
+// capacityWait holds how long and how often to wait for launch capacity
+type capacityWait struct {
+	Timeout      time.Duration
+	PollInterval time.Duration
 }
 
-func (r *InstanceResource) readInstance(ctx context.Context, data *InstanceModel) error {
-	httpReq, err := http.NewRequestWithContext(ctx, "GET",
-		fmt.Sprintf("%s/api/v1/instances/%s", r.client.Endpoint, data.Id.ValueString()),
-		nil)
-	if err != nil {
-		return err
+// LaunchCandidate is an instance type and region pair to attempt a launch in
+type LaunchCandidate struct {
+	InstanceTypeName string
+	RegionName       string
+}
+
+func (c LaunchCandidate) String() string {
+	return fmt.Sprintf("%s in %s", c.InstanceTypeName, c.RegionName)
+}
+
+// Helper methods for API calls
+
+// launchFirstAvailable tries each candidate in order and returns the ID of the
+// first instance launched along with the candidate used. Only insufficient
+// capacity moves on to the next candidate; any other error is returned as is.
+func (r *InstanceResource) launchFirstAvailable(ctx context.Context, launchReq LaunchRequest, candidates []LaunchCandidate) (string, LaunchCandidate, error) {
+	var tried []string
+	var lastErr error
+
+	for _, candidate := range candidates {
+		launchReq.InstanceTypeName = candidate.InstanceTypeName
+		launchReq.RegionName = candidate.RegionName
+
+		instanceId, err := r.launchInstance(ctx, launchReq)
+		if err == nil {
+			return instanceId, candidate, nil
+		}
+
+		if !isInsufficientCapacity(err) {
+			return "", LaunchCandidate{}, err
+		}
+
+		tflog.Info(ctx, "Insufficient capacity for launch candidate, trying next", map[string]interface{}{
+			"instance_type_name": candidate.InstanceTypeName,
+			"region_name":        candidate.RegionName,
+		})
+		tried = append(tried, candidate.String())
+		lastErr = err
 	}
 
-	r.client.AddAuthHeader(httpReq)
+	return "", LaunchCandidate{}, fmt.Errorf("insufficient capacity for every launch candidate (tried %s): %w", strings.Join(tried, ", "), lastErr)
+}
 
-	httpResp, err := r.client.HTTPClient.Do(httpReq)
-	if err != nil {
-		return err
+// launchWithCapacityWait launches the first available candidate. When wait is
+// set and no candidate has capacity, it polls the instance types API until one
+// of them does and launches there, giving up once wait.Timeout has passed or
+// ctx is cancelled.
+func (r *InstanceResource) launchWithCapacityWait(ctx context.Context, launchReq LaunchRequest, candidates []LaunchCandidate, wait *capacityWait) (string, LaunchCandidate, error) {
+	instanceId, launched, err := r.launchFirstAvailable(ctx, launchReq, candidates)
+	if wait == nil || err == nil || !isInsufficientCapacity(err) {
+		return instanceId, launched, err
 	}
-	defer func() {
-		if err := httpResp.Body.Close(); err != nil {
-			tflog.Warn(ctx, "Failed to close response body", map[string]interface{}{"error": err})
+
+	start := time.Now()
+	deadline := start.Add(wait.Timeout)
+	timer := time.NewTimer(wait.Timeout)
+	defer timer.Stop()
+
+	tflog.Info(ctx, "No launch candidate has capacity, waiting for capacity", map[string]interface{}{
+		"timeout":       wait.Timeout.String(),
+		"poll_interval": wait.PollInterval.String(),
+	})
+
+	ticker := time.NewTicker(wait.PollInterval)
+	defer ticker.Stop()
+
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-ctx.Done():
+			return "", LaunchCandidate{}, fmt.Errorf("stopped waiting for capacity after %s: %w", time.Since(start).Round(time.Second), ctx.Err())
+		case <-timer.C:
+			return "", LaunchCandidate{}, fmt.Errorf("no launch candidate had capacity within %s: %w", wait.Timeout, err)
+		case <-ticker.C:
 		}
-	}()
 
-	if httpResp.StatusCode != http.StatusOK {
-		return fmt.Errorf("read API returned status %d", httpResp.StatusCode)
+		available, listErr := r.candidatesWithCapacity(ctx, candidates)
+		if listErr != nil {
+			// A failed poll should not end the wait; the next tick retries.
+			tflog.Warn(ctx, "Unable to poll for capacity", map[string]interface{}{"error": listErr.Error()})
+			continue
+		}
+
+		if len(available) == 0 {
+			tflog.Info(ctx, "Still waiting for capacity", map[string]interface{}{
+				"attempt":   attempt,
+				"elapsed":   time.Since(start).Round(time.Second).String(),
+				"remaining": time.Until(deadline).Round(time.Second).String(),
+			})
+			continue
+		}
+
+		tflog.Info(ctx, "Capacity available, launching", map[string]interface{}{
+			"instance_type_name": available[0].InstanceTypeName,
+			"region_name":        available[0].RegionName,
+		})
+
+		// Capacity can disappear between the poll and the launch, in which
+		// case keep waiting.
+		instanceId, launched, err = r.launchFirstAvailable(ctx, launchReq, available)
+		if err == nil || !isInsufficientCapacity(err) {
+			return instanceId, launched, err
+		}
 	}
+}
 
-	var instanceResp InstanceResponse
-	if err := json.NewDecoder(httpResp.Body).Decode(&instanceResp); err != nil {
-		return err
+// candidatesWithCapacity returns the candidates, in order, that the instance
+// types API currently reports as having capacity. It polls the API directly,
+// since a cached catalog would not show capacity freeing up.
+func (r *InstanceResource) candidatesWithCapacity(ctx context.Context, candidates []LaunchCandidate) ([]LaunchCandidate, error) {
+	instanceTypes, err := r.client.ListInstanceTypes(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	// Update computed fields
-	data.Ip = types.StringValue(instanceResp.Data.Ip)
-	data.PrivateIp = types.StringValue(instanceResp.Data.PrivateIp)
-	data.Hostname = types.StringValue(instanceResp.Data.Hostname)
-	data.Status = types.StringValue(instanceResp.Data.Status)
+	var available []LaunchCandidate
+	for _, candidate := range candidates {
+		instanceType, ok := instanceTypes[candidate.InstanceTypeName]
+		if ok && instanceType.HasCapacityIn(candidate.RegionName) {
+			available = append(available, candidate)
+		}
+	}
 
-	return nil
+	return available, nil
 }
 
-func (r *InstanceResource) terminateInstance(ctx context.Context, instanceId string) error {
-	terminateReq := map[string][]string{
-		"instance_ids": {instanceId},
-	}
+func (r *InstanceResource) launchInstance(ctx context.Context, launchReq LaunchRequest) (string, error) {
+	return r.client.launchInstance(ctx, launchReq)
+}
 
-	jsonData, err := json.Marshal(terminateReq)
+func (r *InstanceResource) readInstance(ctx context.Context, data *InstanceModel) error {
+	instance, err := r.client.readInstance(ctx, data.Id.ValueString())
 	if err != nil {
 		return err
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST",
-		fmt.Sprintf("%s/api/v1/instance-operations/terminate", r.client.Endpoint),
-		strings.NewReader(string(jsonData)))
-	if err != nil {
-		return err
+	// Update computed fields
+	data.Ip = types.StringValue(instance.Ip)
+	data.PrivateIp = types.StringValue(instance.PrivateIp)
+	data.Hostname = types.StringValue(instance.Hostname)
+	data.Status = types.StringValue(instance.Status)
+
+	// An unnamed instance keeps a null name, so that configurations without
+	// one plan nothing
+	if instance.Name != "" || !data.Name.IsNull() {
+		data.Name = types.StringValue(instance.Name)
+	}
+
+	// Imported instances have no configured region or instance type
+	if instance.Region.Name != "" {
+		data.RegionName = types.StringValue(instance.Region.Name)
+	}
+	if instance.InstanceType.Name != "" {
+		data.InstanceTypeName = types.StringValue(instance.InstanceType.Name)
 	}
 
-	r.client.AddAuthHeader(httpReq)
+	data.PriceCentsPerHour = types.Int64Value(instance.InstanceType.PriceCentsPerHour)
+	data.EstimatedCostCents = estimatedCostCents(data.LaunchedAt, instance.InstanceType.PriceCentsPerHour, time.Now())
 
-	httpResp, err := r.client.HTTPClient.Do(httpReq)
-	if err != nil {
-		return err
+	return nil
+}
+
+// nullUnknownComputed sets the computed attributes that only a read of the
+// instance fills in to null, for recording an instance that could not be read.
+func (m *InstanceModel) nullUnknownComputed() {
+	for _, value := range []*types.String{&m.Ip, &m.PrivateIp, &m.Hostname, &m.Status} {
+		if value.IsUnknown() {
+			*value = types.StringNull()
+		}
 	}
-	defer func() {
-		if err := httpResp.Body.Close(); err != nil {
-			tflog.Warn(ctx, "Failed to close response body", map[string]interface{}{"error": err})
+	for _, value := range []*types.Int64{&m.PriceCentsPerHour, &m.EstimatedCostCents} {
+		if value.IsUnknown() {
+			*value = types.Int64Null()
 		}
-	}()
+	}
+}
 
-	if httpResp.StatusCode != http.StatusOK {
-		return fmt.Errorf("terminate API returned status %d", httpResp.StatusCode)
+// estimatedCostCents estimates what an instance has cost since it launched,
+// rounded to the nearest cent. It is null when the launch time is unknown.
+func estimatedCostCents(launchedAt types.String, priceCentsPerHour int64, now time.Time) types.Int64 {
+	if launchedAt.IsNull() || launchedAt.IsUnknown() {
+		return types.Int64Null()
 	}
 
-	return nil
+	launched, err := time.Parse(time.RFC3339, launchedAt.ValueString())
+	if err != nil || now.Before(launched) {
+		return types.Int64Null()
+	}
+
+	hours := now.Sub(launched).Hours()
+	return types.Int64Value(int64(math.Round(hours * float64(priceCentsPerHour))))
+}
+
+func (r *InstanceResource) terminateInstance(ctx context.Context, instanceId string) error {
+	return r.client.TerminateInstances(ctx, []string{instanceId})
 }
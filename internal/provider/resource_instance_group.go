@@ -0,0 +1,721 @@
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/setplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &InstanceGroupResource{}
+var _ resource.ResourceWithModifyPlan = &InstanceGroupResource{}
+var _ resource.ResourceWithUpgradeState = &InstanceGroupResource{}
+
+func NewInstanceGroupResource() resource.Resource {
+	return &InstanceGroupResource{}
+}
+
+// InstanceGroupResource manages a fixed-size group of identical instances.
+type InstanceGroupResource struct {
+	client *ProviderConfig
+}
+
+func (r *InstanceGroupResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_instance_group"
+}
+
+func (r *InstanceGroupResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Version:             instanceGroupSchemaVersion,
+		MarkdownDescription: "Manages a group of identical Lambda Cloud GPU instances launched together, such as the nodes of a multi-node training job.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The unique identifier (ID) of the group, generated by the provider",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"name": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "User-provided name given to every member of the group (max 64 chars). Changing it renames the members in place",
+				Validators: []validator.String{
+					stringvalidator.LengthBetween(0, 64),
+				},
+			},
+			"size": schema.Int64Attribute{
+				Required: true,
+				MarkdownDescription: "Number of instances in the group. Growing the group launches the missing members; " +
+					"shrinking it terminates the members listed in `remove_member_ids`, then the most recently launched members.",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"region_name": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Lambda Cloud region code where the instances will be launched",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.OneOf(lambdaRegions...),
+				},
+			},
+			"instance_type_name": schema.StringAttribute{
+				Required:            true,
+				MarkdownDescription: "Name of the instance type to launch",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"ssh_key_names": schema.SetAttribute{
+				ElementType:         types.StringType,
+				Required:            true,
+				MarkdownDescription: "Set of SSH key names to add to every instance. Their order does not matter",
+				PlanModifiers: []planmodifier.Set{
+					setplanmodifier.RequiresReplace(),
+				},
+			},
+			"file_system_names": schema.SetAttribute{
+				ElementType:         types.StringType,
+				Optional:            true,
+				MarkdownDescription: "Set of filesystem names to mount to every instance. Their order does not matter",
+				PlanModifiers: []planmodifier.Set{
+					setplanmodifier.RequiresReplace(),
+				},
+			},
+			"user_data": schema.StringAttribute{
+				Optional:            true,
+				MarkdownDescription: "cloud-init user data to run on first boot on every instance. See the `cloud_init` provider function",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"remove_member_ids": schema.SetAttribute{
+				ElementType: types.StringType,
+				Optional:    true,
+				MarkdownDescription: "IDs of members to terminate when `size` shrinks, such as a node that is misbehaving. " +
+					"They are terminated before the most recently launched members, and may not outnumber the members the shrink removes. " +
+					"IDs that are no longer members are ignored.",
+			},
+			"members": schema.ListNestedAttribute{
+				Computed:            true,
+				MarkdownDescription: "The instances in the group, in launch order",
+				PlanModifiers: []planmodifier.List{
+					membersUseStateForUnchangedGroup{},
+				},
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The unique identifier (ID) of the instance",
+						},
+						"name": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The name of the instance",
+						},
+						"ip": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The public IP address of the instance",
+						},
+						"private_ip": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The private IP address of the instance",
+						},
+						"hostname": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The hostname of the instance",
+						},
+						"status": schema.StringAttribute{
+							Computed:            true,
+							MarkdownDescription: "The current status of the instance",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *InstanceGroupResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	client, ok := req.ProviderData.(*ProviderConfig)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *ProviderConfig, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = client
+}
+
+func (r *InstanceGroupResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check when destroying, or before the provider is configured
+	if req.Plan.Raw.IsNull() || r.client == nil {
+		return
+	}
+
+	var plan InstanceGroupModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var state *InstanceGroupModel
+	if !req.State.Raw.IsNull() {
+		state = &InstanceGroupModel{}
+		resp.Diagnostics.Append(req.State.Get(ctx, state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	// Enforce the provider's allowlists on values that change
+	policy := &r.client.Policy
+	if state == nil || !plan.Name.Equal(state.Name) {
+		resp.Diagnostics.Append(policy.checkName(path.Root("name"), plan.Name)...)
+	}
+	if changedKnownString(plan.RegionName, state, func(m *InstanceGroupModel) types.String { return m.RegionName }) {
+		resp.Diagnostics.Append(r.client.checkRegion(path.Root("region_name"), plan.RegionName.ValueString())...)
+	}
+	if changedKnownString(plan.InstanceTypeName, state, func(m *InstanceGroupModel) types.String { return m.InstanceTypeName }) {
+		resp.Diagnostics.Append(policy.checkInstanceType(path.Root("instance_type_name"), plan.InstanceTypeName.ValueString())...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if state != nil && !plan.Size.IsUnknown() {
+		resp.Diagnostics.Append(checkRemoveMemberIds(ctx, &plan, state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	// The instance type or size is not known until apply, and a group that
+	// does not change adds no spend
+	if plan.InstanceTypeName.IsUnknown() || plan.Size.IsUnknown() || (state != nil && req.Plan.Raw.Equal(req.State.Raw)) {
+		return
+	}
+
+	planned := plannedSpend{
+		InstanceTypeNames: knownStrings(plan.InstanceTypeName),
+		Count:             plan.Size.ValueInt64(),
+		Path:              path.Root("size"),
+	}
+
+	if state != nil {
+		planned.PriorInstanceTypeName = state.InstanceTypeName.ValueString()
+		planned.PriorCount = state.Size.ValueInt64()
+	}
+
+	resp.Diagnostics.Append(r.client.checkSpend(ctx, planned)...)
+}
+
+// checkRemoveMemberIds rejects a plan listing more current members in
+// remove_member_ids than the change of size terminates, since those members
+// would silently be kept.
+func checkRemoveMemberIds(ctx context.Context, plan, state *InstanceGroupModel) diag.Diagnostics {
+	requested, diags := plan.removeMemberIds(ctx)
+	if diags.HasError() {
+		return diags
+	}
+
+	members, memberDiags := state.members(ctx)
+	diags.Append(memberDiags...)
+	if diags.HasError() {
+		return diags
+	}
+
+	listed := 0
+	for _, instanceId := range memberIds(members) {
+		if containsString(requested, instanceId) {
+			listed++
+		}
+	}
+
+	removing := len(members) - int(plan.Size.ValueInt64())
+	if removing < 0 {
+		removing = 0
+	}
+	if listed > removing {
+		diags.AddAttributeError(path.Root("remove_member_ids"), "Too many members to remove",
+			fmt.Sprintf("remove_member_ids lists %d current members of the group, but the new size only removes %d. "+
+				"Lower size by at least as many members as are listed.", listed, removing))
+	}
+	return diags
+}
+
+// InstanceGroupModel describes the resource data model.
+type InstanceGroupModel struct {
+	Id               types.String `tfsdk:"id"`
+	Name             types.String `tfsdk:"name"`
+	Size             types.Int64  `tfsdk:"size"`
+	RegionName       types.String `tfsdk:"region_name"`
+	InstanceTypeName types.String `tfsdk:"instance_type_name"`
+	SshKeyNames      types.Set    `tfsdk:"ssh_key_names"`
+	FileSystemNames  types.Set    `tfsdk:"file_system_names"`
+	UserData         types.String `tfsdk:"user_data"`
+	RemoveMemberIds  types.Set    `tfsdk:"remove_member_ids"`
+	Members          types.List   `tfsdk:"members"`
+}
+
+// InstanceGroupMemberModel describes one entry of members.
+type InstanceGroupMemberModel struct {
+	Id        types.String `tfsdk:"id"`
+	Name      types.String `tfsdk:"name"`
+	Ip        types.String `tfsdk:"ip"`
+	PrivateIp types.String `tfsdk:"private_ip"`
+	Hostname  types.String `tfsdk:"hostname"`
+	Status    types.String `tfsdk:"status"`
+}
+
+var instanceGroupMemberType = types.ObjectType{
+	AttrTypes: map[string]attr.Type{
+		"id":         types.StringType,
+		"name":       types.StringType,
+		"ip":         types.StringType,
+		"private_ip": types.StringType,
+		"hostname":   types.StringType,
+		"status":     types.StringType,
+	},
+}
+
+func (r *InstanceGroupResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data InstanceGroupModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	launchReq, diags := data.launchRequest(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	groupId, err := newInstanceGroupId()
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to generate instance group ID, got error: %s", err))
+		return
+	}
+	data.Id = types.StringValue(groupId)
+
+	instanceIds, launchErr := r.launchMembers(ctx, launchReq, int(data.Size.ValueInt64()))
+	if len(instanceIds) == 0 {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create instance group, got error: %s", launchErr))
+		return
+	}
+
+	// Read the launched instances to get computed values
+	members, err := r.readMembers(ctx, instanceIds)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read instance group after creation, got error: %s", err))
+
+		// Still record the IDs so the launched instances are not orphaned
+		members = idOnlyMembers(instanceIds)
+	}
+	resp.Diagnostics.Append(data.setMembers(ctx, members)...)
+
+	// Keep the members that did launch in state so they can be cleaned up
+	if launchErr != nil {
+		resp.Diagnostics.AddError(
+			"Client Error",
+			fmt.Sprintf("Launched %d of %d instances in the group, got error: %s", len(instanceIds), data.Size.ValueInt64(), launchErr),
+		)
+	}
+
+	tflog.Trace(ctx, "created an instance group resource", map[string]interface{}{"size": len(instanceIds)})
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *InstanceGroupResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data InstanceGroupModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	current, diags := data.members(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	members, err := r.readMembers(ctx, memberIds(current))
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read instance group, got error: %s", err))
+		return
+	}
+
+	if len(members) == 0 {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+
+	// Members terminated outside Terraform shrink the group, so the next plan
+	// launches replacements.
+	data.Size = types.Int64Value(int64(len(members)))
+	resp.Diagnostics.Append(data.setMembers(ctx, members)...)
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *InstanceGroupResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state InstanceGroupModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	current, diags := state.members(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	instanceIds := memberIds(current)
+	size := int(plan.Size.ValueInt64())
+
+	// Rename the current members first, members launched below get the new
+	// name from the launch request
+	if !plan.Name.Equal(state.Name) {
+		if err := r.renameMembers(ctx, instanceIds, plan.Name.ValueString()); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to rename instance group, got error: %s", err))
+			return
+		}
+	}
+
+	var updateErr error
+	switch {
+	case size > len(instanceIds):
+		launchReq, diags := plan.launchRequest(ctx)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		var launched []string
+		launched, updateErr = r.launchMembers(ctx, launchReq, size-len(instanceIds))
+		instanceIds = append(instanceIds, launched...)
+
+	case size < len(instanceIds):
+		requested, diags := plan.removeMemberIds(ctx)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		removed := membersToRemove(instanceIds, requested, len(instanceIds)-size)
+		updateErr = r.client.TerminateInstances(ctx, removed)
+		if updateErr == nil {
+			instanceIds = withoutIds(instanceIds, removed)
+		}
+	}
+
+	members, err := r.readMembers(ctx, instanceIds)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read instance group after resizing, got error: %s", err))
+
+		// Still record every ID, including newly launched members, so that
+		// no instance is orphaned
+		members = idOnlyMembers(instanceIds)
+	}
+
+	plan.Id = state.Id
+	resp.Diagnostics.Append(plan.setMembers(ctx, members)...)
+
+	if updateErr != nil {
+		plan.Size = types.Int64Value(int64(len(members)))
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to resize instance group to %d, got error: %s", size, updateErr))
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *InstanceGroupResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data InstanceGroupModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	members, diags := data.members(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || len(members) == 0 {
+		return
+	}
+
+	// Terminate every member in one call
+	err := r.client.TerminateInstances(ctx, memberIds(members))
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete instance group, got error: %s", err))
+		return
+	}
+}
+
+// launchRequest builds the launch request shared by every member.
+func (m *InstanceGroupModel) launchRequest(ctx context.Context) (LaunchRequest, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	launchReq := LaunchRequest{
+		RegionName:       m.RegionName.ValueString(),
+		InstanceTypeName: m.InstanceTypeName.ValueString(),
+		UserData:         m.UserData.ValueString(),
+	}
+
+	diags.Append(m.SshKeyNames.ElementsAs(ctx, &launchReq.SshKeyNames, false)...)
+	if !m.FileSystemNames.IsNull() {
+		diags.Append(m.FileSystemNames.ElementsAs(ctx, &launchReq.FileSystemNames, false)...)
+	}
+
+	if !m.Name.IsNull() {
+		name := m.Name.ValueString()
+		launchReq.Name = &name
+	}
+
+	return launchReq, diags
+}
+
+// members returns the members recorded in the model.
+func (m *InstanceGroupModel) members(ctx context.Context) ([]InstanceGroupMemberModel, diag.Diagnostics) {
+	var members []InstanceGroupMemberModel
+	if m.Members.IsNull() || m.Members.IsUnknown() {
+		return members, nil
+	}
+	diags := m.Members.ElementsAs(ctx, &members, false)
+	return members, diags
+}
+
+// setMembers records members in the model.
+func (m *InstanceGroupModel) setMembers(ctx context.Context, members []InstanceGroupMemberModel) diag.Diagnostics {
+	if members == nil {
+		members = []InstanceGroupMemberModel{}
+	}
+	value, diags := types.ListValueFrom(ctx, instanceGroupMemberType, members)
+	m.Members = value
+	return diags
+}
+
+// launchMembers launches count instances, in as few launch calls as the API
+// allows. The IDs of any instances that were launched are returned even when
+// a later call fails, so the caller can keep track of them.
+func (r *InstanceGroupResource) launchMembers(ctx context.Context, launchReq LaunchRequest, count int) ([]string, error) {
+	var instanceIds []string
+
+	for len(instanceIds) < count {
+		launchReq.Quantity = count - len(instanceIds)
+
+		launched, err := r.client.LaunchInstances(ctx, launchReq)
+		if err != nil {
+			return instanceIds, err
+		}
+
+		if len(launched) < launchReq.Quantity {
+			tflog.Debug(ctx, "Launch API returned fewer instances than requested, launching the rest", map[string]interface{}{
+				"requested": launchReq.Quantity,
+				"launched":  len(launched),
+			})
+		}
+		if len(launched) > launchReq.Quantity {
+			r.terminateExtra(ctx, launched[launchReq.Quantity:])
+			launched = launched[:launchReq.Quantity]
+		}
+
+		instanceIds = append(instanceIds, launched...)
+	}
+
+	return instanceIds, nil
+}
+
+// terminateExtra terminates instances the launch API returned beyond the
+// requested quantity, so that the group keeps the configured size.
+func (r *InstanceGroupResource) terminateExtra(ctx context.Context, instanceIds []string) {
+	tflog.Warn(ctx, "Terminating instances that the instance group did not ask for", map[string]interface{}{"instance_ids": instanceIds})
+
+	if err := r.client.TerminateInstances(ctx, instanceIds); err != nil {
+		tflog.Error(ctx, "Unable to terminate instances that the instance group did not ask for, terminate them by hand", map[string]interface{}{
+			"instance_ids": instanceIds,
+			"error":        err.Error(),
+		})
+	}
+}
+
+// renameMembers gives the given members a new name.
+func (r *InstanceGroupResource) renameMembers(ctx context.Context, instanceIds []string, name string) error {
+	for _, instanceId := range instanceIds {
+		if err := r.client.RenameInstance(ctx, instanceId, name); err != nil {
+			return fmt.Errorf("instance %s: %w", instanceId, err)
+		}
+	}
+	return nil
+}
+
+// readMembers reads the given instances, in order. Instances that no longer
+// exist are dropped from the result.
+func (r *InstanceGroupResource) readMembers(ctx context.Context, instanceIds []string) ([]InstanceGroupMemberModel, error) {
+	members := make([]InstanceGroupMemberModel, 0, len(instanceIds))
+
+	for _, instanceId := range instanceIds {
+		instance, err := r.client.readInstance(ctx, instanceId)
+		if isNotFound(err) {
+			tflog.Warn(ctx, "Instance group member no longer exists", map[string]interface{}{"instance_id": instanceId})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		members = append(members, InstanceGroupMemberModel{
+			Id:        types.StringValue(instanceId),
+			Name:      types.StringValue(instance.Name),
+			Ip:        types.StringValue(instance.Ip),
+			PrivateIp: types.StringValue(instance.PrivateIp),
+			Hostname:  types.StringValue(instance.Hostname),
+			Status:    types.StringValue(instance.Status),
+		})
+	}
+
+	return members, nil
+}
+
+// idOnlyMembers returns members that only carry their IDs, for recording
+// instances that could not be read.
+func idOnlyMembers(instanceIds []string) []InstanceGroupMemberModel {
+	members := make([]InstanceGroupMemberModel, 0, len(instanceIds))
+	for _, instanceId := range instanceIds {
+		members = append(members, InstanceGroupMemberModel{
+			Id:        types.StringValue(instanceId),
+			Name:      types.StringValue(""),
+			Ip:        types.StringValue(""),
+			PrivateIp: types.StringValue(""),
+			Hostname:  types.StringValue(""),
+			Status:    types.StringValue(""),
+		})
+	}
+	return members
+}
+
+// removeMemberIds returns the IDs listed in remove_member_ids.
+func (m *InstanceGroupModel) removeMemberIds(ctx context.Context) ([]string, diag.Diagnostics) {
+	var instanceIds []string
+	if m.RemoveMemberIds.IsNull() || m.RemoveMemberIds.IsUnknown() {
+		return instanceIds, nil
+	}
+	diags := m.RemoveMemberIds.ElementsAs(ctx, &instanceIds, false)
+	return instanceIds, diags
+}
+
+// membersToRemove picks count of instanceIds to terminate: the requested
+// members first, then the most recently launched. Requested IDs that are not
+// members are ignored.
+func membersToRemove(instanceIds, requested []string, count int) []string {
+	removed := make([]string, 0, count)
+	for _, instanceId := range instanceIds {
+		if len(removed) < count && containsString(requested, instanceId) {
+			removed = append(removed, instanceId)
+		}
+	}
+	for i := len(instanceIds) - 1; i >= 0 && len(removed) < count; i-- {
+		if !containsString(removed, instanceIds[i]) {
+			removed = append(removed, instanceIds[i])
+		}
+	}
+	return removed
+}
+
+// withoutIds returns instanceIds, in order, without the removed ones.
+func withoutIds(instanceIds, removed []string) []string {
+	kept := make([]string, 0, len(instanceIds))
+	for _, instanceId := range instanceIds {
+		if !containsString(removed, instanceId) {
+			kept = append(kept, instanceId)
+		}
+	}
+	return kept
+}
+
+func memberIds(members []InstanceGroupMemberModel) []string {
+	instanceIds := make([]string, 0, len(members))
+	for _, member := range members {
+		instanceIds = append(instanceIds, member.Id.ValueString())
+	}
+	return instanceIds
+}
+
+// newInstanceGroupId generates a random identifier for a group. The API has
+// no group object, so the ID only exists in Terraform state.
+func newInstanceGroupId() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+var _ planmodifier.List = membersUseStateForUnchangedGroup{}
+
+// membersUseStateForUnchangedGroup keeps the known members in the plan unless
+// the group is being resized or renamed, in which case they are left unknown.
+type membersUseStateForUnchangedGroup struct{}
+
+func (m membersUseStateForUnchangedGroup) Description(ctx context.Context) string {
+	return "Uses the prior members unless the group size or name changes."
+}
+
+func (m membersUseStateForUnchangedGroup) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m membersUseStateForUnchangedGroup) PlanModifyList(ctx context.Context, req planmodifier.ListRequest, resp *planmodifier.ListResponse) {
+	if req.StateValue.IsNull() || !req.PlanValue.IsUnknown() {
+		return
+	}
+
+	var planSize, stateSize types.Int64
+	var planName, stateName types.String
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("size"), &planSize)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("size"), &stateSize)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("name"), &planName)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("name"), &stateName)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if planSize.Equal(stateSize) && planName.Equal(stateName) {
+		resp.PlanValue = req.StateValue
+	}
+}
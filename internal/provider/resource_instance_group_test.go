@@ -0,0 +1,404 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-testing/config"
+	"github.com/hashicorp/terraform-plugin-testing/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+
+	"github.com/albertocavalcante/terraform-provider-lambda/internal/fakelambda"
+)
+
+func TestAccInstanceGroupResource(t *testing.T) {
+	env := testAccPreCheck(t)
+	env.skipUnlessFake(t)
+	name := testAccResourcePrefix + acctest.RandString(8)
+
+	var firstMemberId string
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckInstanceGroupDestroy(env),
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccInstanceGroupResourceConfig(env, name, 2),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("lambda_instance_group.test", plancheck.ResourceActionCreate),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("lambda_instance_group.test", tfjsonpath.New("members"), knownvalue.ListSizeExact(2)),
+					statecheck.ExpectKnownValue("lambda_instance_group.test", tfjsonpath.New("members").AtSliceIndex(0).AtMapKey("name"), knownvalue.StringExact(name)),
+					statecheck.ExpectKnownValue("lambda_instance_group.test", tfjsonpath.New("members").AtSliceIndex(1).AtMapKey("status"), knownvalue.NotNull()),
+				},
+				Check: testAccCaptureAttr("lambda_instance_group.test", "members.0.id", &firstMemberId),
+			},
+			// Growing the group launches the missing member and keeps the
+			// existing ones
+			{
+				Config: testAccInstanceGroupResourceConfig(env, name, 3),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("lambda_instance_group.test", plancheck.ResourceActionUpdate),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("lambda_instance_group.test", tfjsonpath.New("members"), knownvalue.ListSizeExact(3)),
+				},
+				Check: resource.TestCheckResourceAttrWith("lambda_instance_group.test", "members.0.id", func(value string) error {
+					if value != firstMemberId {
+						return fmt.Errorf("expected the first member %s to be kept, got %s", firstMemberId, value)
+					}
+					return nil
+				}),
+			},
+			// Shrinking the group terminates the most recently launched
+			// member
+			{
+				Config: testAccInstanceGroupResourceConfig(env, name, 2),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("lambda_instance_group.test", tfjsonpath.New("members"), knownvalue.ListSizeExact(2)),
+					statecheck.ExpectKnownValue("lambda_instance_group.test", tfjsonpath.New("members").AtSliceIndex(0).AtMapKey("id"), knownvalue.StringFunc(func(value string) error {
+						if value != firstMemberId {
+							return fmt.Errorf("expected the first member %s to be kept, got %s", firstMemberId, value)
+						}
+						return nil
+					})),
+				},
+			},
+			// Renaming the group renames the members in place
+			{
+				Config: testAccInstanceGroupResourceConfig(env, name+"-renamed", 2),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("lambda_instance_group.test", plancheck.ResourceActionUpdate),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("lambda_instance_group.test", tfjsonpath.New("members").AtSliceIndex(0).AtMapKey("id"), knownvalue.StringFunc(func(value string) error {
+						if value != firstMemberId {
+							return fmt.Errorf("expected the first member %s to be kept, got %s", firstMemberId, value)
+						}
+						return nil
+					})),
+					statecheck.ExpectKnownValue("lambda_instance_group.test", tfjsonpath.New("members").AtSliceIndex(0).AtMapKey("name"), knownvalue.StringExact(name+"-renamed")),
+					statecheck.ExpectKnownValue("lambda_instance_group.test", tfjsonpath.New("members").AtSliceIndex(1).AtMapKey("name"), knownvalue.StringExact(name+"-renamed")),
+				},
+			},
+			// ImportState is not supported, and Delete testing automatically
+			// occurs in TestCase
+		},
+	})
+}
+
+func TestAccInstanceGroupResource_removeMemberIds(t *testing.T) {
+	env := testAccPreCheck(t)
+	env.skipUnlessFake(t)
+	name := testAccResourcePrefix + acctest.RandString(8)
+
+	var firstMemberId, secondMemberId string
+
+	removeConfig := func(size int) string {
+		return env.providerConfig() + fmt.Sprintf(`
+variable "remove_member_id" {
+  type = string
+}
+
+resource "lambda_instance_group" "test" {
+  name               = %[1]q
+  size               = %[2]d
+  region_name        = %[3]q
+  instance_type_name = %[4]q
+  ssh_key_names      = [%[5]q]
+  remove_member_ids  = [var.remove_member_id]
+}
+`, name, size, env.RegionName, env.InstanceTypeName, env.SshKeyName)
+	}
+	variables := config.Variables{
+		"remove_member_id": testAccCapturedVariable{&firstMemberId},
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckInstanceGroupDestroy(env),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccInstanceGroupResourceConfig(env, name, 3),
+				Check: resource.ComposeTestCheckFunc(
+					testAccCaptureAttr("lambda_instance_group.test", "members.0.id", &firstMemberId),
+					testAccCaptureAttr("lambda_instance_group.test", "members.1.id", &secondMemberId),
+				),
+			},
+			// Listing a member without shrinking the group would keep it
+			{
+				Config:          removeConfig(3),
+				ConfigVariables: variables,
+				ExpectError:     regexp.MustCompile(`Too many members to remove`),
+			},
+			// Shrinking terminates the listed member instead of the most
+			// recently launched one
+			{
+				Config:          removeConfig(2),
+				ConfigVariables: variables,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("lambda_instance_group.test", plancheck.ResourceActionUpdate),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("lambda_instance_group.test", tfjsonpath.New("size"), knownvalue.Int64Exact(2)),
+					statecheck.ExpectKnownValue("lambda_instance_group.test", tfjsonpath.New("members"), knownvalue.ListSizeExact(2)),
+				},
+				Check: resource.ComposeTestCheckFunc(
+					resource.TestCheckResourceAttrPtr("lambda_instance_group.test", "members.0.id", &secondMemberId),
+					func(*terraform.State) error {
+						instance, err := env.client().GetInstance(context.Background(), firstMemberId)
+						if isNotFound(err) {
+							return nil
+						}
+						if err != nil {
+							return err
+						}
+						if instance.Status != "terminating" && instance.Status != "terminated" {
+							return fmt.Errorf("expected the listed member %s to be terminated, got status %s", firstMemberId, instance.Status)
+						}
+						return nil
+					},
+				),
+			},
+			// The listed ID is no longer a member, so keeping it in the
+			// configuration plans nothing
+			{
+				Config:          removeConfig(2),
+				ConfigVariables: variables,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestAccInstanceGroupResource_partialLaunch(t *testing.T) {
+	env := testAccPreCheck(t)
+	env.skipUnlessFake(t)
+	name := testAccResourcePrefix + acctest.RandString(8)
+
+	// Each launch call launches at most 2 instances and there is room for
+	// only 2, so the call for the third member fails
+	env.Fake.SetLaunchLimit(2)
+	env.Fake.SetCapacity(env.InstanceTypeName, env.RegionName, 2)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckInstanceGroupDestroy(env),
+		Steps: []resource.TestStep{
+			{
+				Config:      testAccInstanceGroupResourceConfig(env, name, 3),
+				ExpectError: regexp.MustCompile(`Launched 2 of 3 instances in the group`),
+			},
+			// The launched members were kept in state, so the tainted group
+			// is replaced and nothing is orphaned
+			{
+				PreConfig: func() {
+					if got := len(env.Fake.Instances()); got != 2 {
+						t.Fatalf("expected 2 launched instances, got %d", got)
+					}
+					env.Fake.SetCapacity(env.InstanceTypeName, env.RegionName, 10)
+				},
+				Config: testAccInstanceGroupResourceConfig(env, name, 3),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("lambda_instance_group.test", plancheck.ResourceActionReplace),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("lambda_instance_group.test", tfjsonpath.New("members"), knownvalue.ListSizeExact(3)),
+				},
+			},
+		},
+	})
+}
+
+func TestAccInstanceGroupResource_memberDisappears(t *testing.T) {
+	env := testAccPreCheck(t)
+	env.skipUnlessFake(t)
+	name := testAccResourcePrefix + acctest.RandString(8)
+
+	var firstMemberId string
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckInstanceGroupDestroy(env),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccInstanceGroupResourceConfig(env, name, 2),
+				Check:  testAccCaptureAttr("lambda_instance_group.test", "members.0.id", &firstMemberId),
+			},
+			// A member is terminated outside Terraform, so the refresh drops
+			// it and the plan launches a replacement
+			{
+				PreConfig: func() {
+					if err := env.Fake.DeleteInstance(firstMemberId); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: testAccInstanceGroupResourceConfig(env, name, 2),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("lambda_instance_group.test", plancheck.ResourceActionUpdate),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("lambda_instance_group.test", tfjsonpath.New("size"), knownvalue.Int64Exact(2)),
+					statecheck.ExpectKnownValue("lambda_instance_group.test", tfjsonpath.New("members"), knownvalue.ListSizeExact(2)),
+				},
+				Check: resource.TestCheckResourceAttrWith("lambda_instance_group.test", "members.0.id", func(value string) error {
+					if value == firstMemberId {
+						return fmt.Errorf("expected the terminated member %s to be dropped", value)
+					}
+					return nil
+				}),
+			},
+		},
+	})
+}
+
+func TestMembersToRemove(t *testing.T) {
+	instanceIds := []string{"a", "b", "c", "d"}
+
+	for name, tc := range map[string]struct {
+		requested []string
+		count     int
+		want      []string
+	}{
+		"newest first":                {count: 2, want: []string{"d", "c"}},
+		"requested first":             {requested: []string{"b"}, count: 2, want: []string{"b", "d"}},
+		"requested in order":          {requested: []string{"c", "a"}, count: 2, want: []string{"a", "c"}},
+		"unknown IDs are ignored":     {requested: []string{"x", "a"}, count: 1, want: []string{"a"}},
+		"more requested than removed": {requested: []string{"a", "b"}, count: 1, want: []string{"a"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got := membersToRemove(instanceIds, tc.requested, tc.count)
+			if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+				t.Errorf("membersToRemove() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestInstanceGroupLaunchMembersTerminatesExtra(t *testing.T) {
+	fake := fakelambda.New(fakelambda.Options{})
+	if _, err := fake.AddSshKey("group"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The API launches one instance more than each launch asks for
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/instance-operations/launch" {
+			var launchReq map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&launchReq); err != nil {
+				t.Error(err)
+			}
+			quantity, _ := launchReq["quantity"].(float64)
+			launchReq["quantity"] = max(quantity, 1) + 1
+			body, _ := json.Marshal(launchReq)
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+		}
+		fake.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	r := &InstanceGroupResource{client: &ProviderConfig{ApiKey: "test", Endpoint: server.URL, HTTPClient: &http.Client{}}}
+	launched, err := r.launchMembers(context.Background(), LaunchRequest{
+		RegionName:       "us-east-1",
+		InstanceTypeName: "gpu_1x_a10",
+		SshKeyNames:      []string{"group"},
+	}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(launched) != 2 {
+		t.Fatalf("expected 2 members, got %v", launched)
+	}
+	for _, instance := range fake.Instances() {
+		if !containsString(launched, instance.Id) && instance.Status != fakelambda.StatusTerminating {
+			t.Errorf("expected the extra instance %s to be terminated, got status %s", instance.Id, instance.Status)
+		}
+	}
+}
+
+func testAccInstanceGroupResourceConfig(env *testAccEnv, name string, size int) string {
+	return env.providerConfig() + fmt.Sprintf(`
+resource "lambda_instance_group" "test" {
+  name               = %[1]q
+  size               = %[2]d
+  region_name        = %[3]q
+  instance_type_name = %[4]q
+  ssh_key_names      = [%[5]q]
+}
+`, name, size, env.RegionName, env.InstanceTypeName, env.SshKeyName)
+}
+
+// testAccCapturedVariable is a configuration variable holding a value captured
+// by an earlier step, read when the step that uses it runs.
+type testAccCapturedVariable struct {
+	value *string
+}
+
+func (v testAccCapturedVariable) MarshalJSON() ([]byte, error) {
+	return json.Marshal(*v.value)
+}
+
+// testAccCheckInstanceGroupDestroy checks that every member of every group in
+// state is gone or on its way out.
+func testAccCheckInstanceGroupDestroy(env *testAccEnv) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		client := env.client()
+
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != "lambda_instance_group" {
+				continue
+			}
+
+			for key, instanceId := range rs.Primary.Attributes {
+				if !regexp.MustCompile(`^members\.\d+\.id$`).MatchString(key) {
+					continue
+				}
+
+				instance, err := client.GetInstance(context.Background(), instanceId)
+				if isNotFound(err) {
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				if instance.Status != "terminating" && instance.Status != "terminated" {
+					return fmt.Errorf("instance %s of group %s still exists with status %s", instanceId, rs.Primary.ID, instance.Status)
+				}
+			}
+		}
+
+		return nil
+	}
+}
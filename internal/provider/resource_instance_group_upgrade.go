@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// instanceGroupSchemaVersion is the version of the lambda_instance_group
+// schema, maintained like instanceSchemaVersion.
+//
+// Version 0 is the schema of releases before the schema was versioned.
+// Version 1 turns ssh_key_names and file_system_names from lists into sets.
+const instanceGroupSchemaVersion = 1
+
+// UpgradeState upgrades state written by every prior schema version straight
+// to the current one.
+func (r *InstanceGroupResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	schemaV0 := instanceGroupSchemaV0()
+
+	return map[int64]resource.StateUpgrader{
+		0: {
+			PriorSchema:   &schemaV0,
+			StateUpgrader: upgradeInstanceGroupStateV0,
+		},
+	}
+}
+
+// instanceGroupModelV0 is the lambda_instance_group state of schema version 0.
+type instanceGroupModelV0 struct {
+	Id               types.String `tfsdk:"id"`
+	Name             types.String `tfsdk:"name"`
+	Size             types.Int64  `tfsdk:"size"`
+	RegionName       types.String `tfsdk:"region_name"`
+	InstanceTypeName types.String `tfsdk:"instance_type_name"`
+	SshKeyNames      types.List   `tfsdk:"ssh_key_names"`
+	FileSystemNames  types.List   `tfsdk:"file_system_names"`
+	UserData         types.String `tfsdk:"user_data"`
+	Members          types.List   `tfsdk:"members"`
+}
+
+// instanceGroupSchemaV0 is the lambda_instance_group schema of version 0,
+// frozen. Only the attribute types matter for reading prior state.
+func instanceGroupSchemaV0() schema.Schema {
+	return schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id":                 schema.StringAttribute{Computed: true},
+			"name":               schema.StringAttribute{Optional: true},
+			"size":               schema.Int64Attribute{Required: true},
+			"region_name":        schema.StringAttribute{Required: true},
+			"instance_type_name": schema.StringAttribute{Required: true},
+			"ssh_key_names":      schema.ListAttribute{ElementType: types.StringType, Required: true},
+			"file_system_names":  schema.ListAttribute{ElementType: types.StringType, Optional: true},
+			"user_data":          schema.StringAttribute{Optional: true},
+			"members": schema.ListNestedAttribute{
+				Computed: true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id":         schema.StringAttribute{Computed: true},
+						"name":       schema.StringAttribute{Computed: true},
+						"ip":         schema.StringAttribute{Computed: true},
+						"private_ip": schema.StringAttribute{Computed: true},
+						"hostname":   schema.StringAttribute{Computed: true},
+						"status":     schema.StringAttribute{Computed: true},
+					},
+				},
+			},
+		},
+	}
+}
+
+// upgradeInstanceGroupStateV0 upgrades state of version 0 by turning the SSH
+// key and file system lists into sets.
+func upgradeInstanceGroupStateV0(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	var prior instanceGroupModelV0
+	resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	upgraded := InstanceGroupModel{
+		Id:               prior.Id,
+		Name:             prior.Name,
+		Size:             prior.Size,
+		RegionName:       prior.RegionName,
+		InstanceTypeName: prior.InstanceTypeName,
+		UserData:         prior.UserData,
+		RemoveMemberIds:  types.SetNull(types.StringType),
+		Members:          prior.Members,
+	}
+
+	var diags diag.Diagnostics
+	upgraded.SshKeyNames, diags = stringListToSet(ctx, prior.SshKeyNames)
+	resp.Diagnostics.Append(diags...)
+	upgraded.FileSystemNames, diags = stringListToSet(ctx, prior.FileSystemNames)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &upgraded)...)
+}
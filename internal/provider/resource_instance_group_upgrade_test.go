@@ -0,0 +1,34 @@
+package provider
+
+import "testing"
+
+func TestInstanceGroupResourceUpgradeState(t *testing.T) {
+	var upgraded InstanceGroupModel
+	upgradeTestState(t, NewInstanceGroupResource(), "lambda_instance_group", 0, `{
+  "id": "3f7c5b0e-2d4a-4c39-9a61-0f1b2c3d4e5f",
+  "name": "training",
+  "size": 2,
+  "region_name": "us-east-1",
+  "instance_type_name": "gpu_8x_h100_sxm5",
+  "ssh_key_names": ["deploy", "ci"],
+  "file_system_names": ["datasets"],
+  "user_data": null,
+  "members": [
+    {"id": "a1", "name": "training", "ip": "198.51.100.2", "private_ip": "10.0.2.100", "hostname": "a1.example", "status": "active"},
+    {"id": "a2", "name": "training", "ip": "198.51.100.3", "private_ip": "10.0.2.101", "hostname": "a2.example", "status": "active"}
+  ]
+}`, &upgraded)
+
+	if got := upgraded.Size.ValueInt64(); got != 2 {
+		t.Errorf("expected the size to carry over, got %d", got)
+	}
+	if got := len(upgraded.SshKeyNames.Elements()); got != 2 {
+		t.Errorf("expected 2 SSH key names, got %d", got)
+	}
+	if got := len(upgraded.FileSystemNames.Elements()); got != 1 {
+		t.Errorf("expected 1 file system name, got %d", got)
+	}
+	if got := len(upgraded.Members.Elements()); got != 2 {
+		t.Errorf("expected the 2 members to carry over, got %d", got)
+	}
+}
@@ -0,0 +1,511 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	fwresource "github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/helper/acctest"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+
+	"github.com/albertocavalcante/terraform-provider-lambda/internal/fakelambda"
+)
+
+func TestAccInstanceResource(t *testing.T) {
+	env := testAccPreCheck(t)
+	name := testAccResourcePrefix + acctest.RandString(8)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckInstanceDestroy(env),
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccInstanceResourceConfig(env, name),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("lambda_instance.test", plancheck.ResourceActionCreate),
+						plancheck.ExpectUnknownValue("lambda_instance.test", tfjsonpath.New("id")),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("lambda_instance.test", tfjsonpath.New("id"), knownvalue.StringRegexp(regexp.MustCompile(`^[0-9a-f]+$`))),
+					statecheck.ExpectKnownValue("lambda_instance.test", tfjsonpath.New("name"), knownvalue.StringExact(name)),
+					statecheck.ExpectKnownValue("lambda_instance.test", tfjsonpath.New("region_name"), knownvalue.StringExact(env.RegionName)),
+					statecheck.ExpectKnownValue("lambda_instance.test", tfjsonpath.New("instance_type_name"), knownvalue.StringExact(env.InstanceTypeName)),
+					statecheck.ExpectKnownValue("lambda_instance.test", tfjsonpath.New("status"), knownvalue.NotNull()),
+					statecheck.ExpectKnownValue("lambda_instance.test", tfjsonpath.New("price_cents_per_hour"), knownvalue.NotNull()),
+					statecheck.ExpectKnownValue("lambda_instance.test", tfjsonpath.New("launched_at"), knownvalue.NotNull()),
+				},
+			},
+			// A refresh with unchanged configuration plans nothing
+			{
+				Config: testAccInstanceResourceConfig(env, name),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+			// Renaming the instance updates it in place
+			{
+				Config: testAccInstanceResourceConfig(env, name+"-renamed"),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("lambda_instance.test", plancheck.ResourceActionUpdate),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("lambda_instance.test", tfjsonpath.New("name"), knownvalue.StringExact(name+"-renamed")),
+				},
+			},
+			// ImportState testing. The read API does not return the launch
+			// settings, and the launch time is only known to the provider
+			// that created the instance.
+			{
+				ResourceName:      "lambda_instance.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateVerifyIgnore: []string{
+					"ssh_key_names",
+					"file_system_names",
+					"user_data",
+					"launched_at",
+					"estimated_cost_cents",
+				},
+			},
+			// Delete testing automatically occurs in TestCase
+		},
+	})
+}
+
+func TestAccInstanceResource_disappears(t *testing.T) {
+	env := testAccPreCheck(t)
+	env.skipUnlessFake(t)
+	name := testAccResourcePrefix + acctest.RandString(8)
+
+	var instanceId string
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckInstanceDestroy(env),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccInstanceResourceConfig(env, name),
+				Check:  testAccCaptureAttr("lambda_instance.test", "id", &instanceId),
+			},
+			// The instance is terminated outside Terraform, so the refresh
+			// drops it and the plan launches a new one
+			{
+				PreConfig: func() {
+					if err := env.Fake.DeleteInstance(instanceId); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: testAccInstanceResourceConfig(env, name),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("lambda_instance.test", plancheck.ResourceActionCreate),
+					},
+				},
+				Check: resource.TestCheckResourceAttrWith("lambda_instance.test", "id", func(value string) error {
+					if value == instanceId {
+						return fmt.Errorf("expected a new instance, still have %s", value)
+					}
+					return nil
+				}),
+			},
+		},
+	})
+}
+
+func TestAccInstanceResource_statusDrift(t *testing.T) {
+	env := testAccPreCheck(t)
+	env.skipUnlessFake(t)
+	name := testAccResourcePrefix + acctest.RandString(8)
+
+	var instanceId string
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckInstanceDestroy(env),
+		Steps: []resource.TestStep{
+			{
+				Config: testAccInstanceResourceConfig(env, name),
+				Check:  testAccCaptureAttr("lambda_instance.test", "id", &instanceId),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("lambda_instance.test", tfjsonpath.New("status"), knownvalue.StringExact(fakelambda.StatusActive)),
+				},
+			},
+			// A status change is picked up by the refresh and needs no
+			// change to the instance
+			{
+				PreConfig: func() {
+					if err := env.Fake.SetInstanceStatus(instanceId, fakelambda.StatusUnhealthy); err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: testAccInstanceResourceConfig(env, name),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("lambda_instance.test", tfjsonpath.New("status"), knownvalue.StringExact(fakelambda.StatusUnhealthy)),
+				},
+			},
+		},
+	})
+}
+
+func TestAccInstanceResource_launchCandidatesFallback(t *testing.T) {
+	env := testAccPreCheck(t)
+	env.skipUnlessFake(t)
+	name := testAccResourcePrefix + acctest.RandString(8)
+
+	env.Fake.SetCapacity("gpu_1x_a10", "us-east-1", 0)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckInstanceDestroy(env),
+		Steps: []resource.TestStep{
+			{
+				Config: env.providerConfig() + fmt.Sprintf(`
+resource "lambda_instance" "test" {
+  name          = %[1]q
+  ssh_key_names = [%[2]q]
+
+  launch_candidates = [
+    { instance_type_name = "gpu_1x_a10", region_name = "us-east-1" },
+    { instance_type_name = "gpu_1x_a10", region_name = "us-west-1" },
+  ]
+}
+`, name, env.SshKeyName),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue("lambda_instance.test", tfjsonpath.New("region_name"), knownvalue.StringExact("us-west-1")),
+				},
+			},
+		},
+	})
+}
+
+func TestLaunchWithCapacityWait(t *testing.T) {
+	// newCapacityWaitTest returns a resource against an API stand-in without
+	// capacity for gpu_1x_a10 in us-east-1.
+	newCapacityWaitTest := func(t *testing.T) (*InstanceResource, *fakelambda.Server) {
+		t.Helper()
+
+		fake := fakelambda.New(fakelambda.Options{})
+		if _, err := fake.AddSshKey("capacity"); err != nil {
+			t.Fatal(err)
+		}
+		fake.SetCapacity("gpu_1x_a10", "us-east-1", 0)
+		server := httptest.NewServer(fake)
+		t.Cleanup(server.Close)
+
+		client := &ProviderConfig{ApiKey: "test", Endpoint: server.URL, HTTPClient: &http.Client{}}
+		return &InstanceResource{client: client}, fake
+	}
+	launchReq := LaunchRequest{SshKeyNames: []string{"capacity"}}
+	candidates := []LaunchCandidate{{InstanceTypeName: "gpu_1x_a10", RegionName: "us-east-1"}}
+
+	t.Run("times out without waiting for the next poll", func(t *testing.T) {
+		r, fake := newCapacityWaitTest(t)
+
+		start := time.Now()
+		_, _, err := r.launchWithCapacityWait(context.Background(), launchReq, candidates, &capacityWait{
+			Timeout:      200 * time.Millisecond,
+			PollInterval: time.Hour,
+		})
+		if err == nil || !strings.Contains(err.Error(), "no launch candidate had capacity within 200ms") {
+			t.Fatalf("expected the wait to time out, got %v", err)
+		}
+		if !isInsufficientCapacity(err) {
+			t.Errorf("expected the capacity error to be wrapped, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("expected to give up at the timeout, returned after %s", elapsed)
+		}
+		if got := len(fake.Instances()); got != 0 {
+			t.Errorf("expected no instances, got %d", got)
+		}
+	})
+
+	t.Run("launches once capacity frees up", func(t *testing.T) {
+		r, fake := newCapacityWaitTest(t)
+
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			fake.SetCapacity("gpu_1x_a10", "us-east-1", 1)
+		}()
+
+		instanceId, launched, err := r.launchWithCapacityWait(context.Background(), launchReq, candidates, &capacityWait{
+			Timeout:      10 * time.Second,
+			PollInterval: 20 * time.Millisecond,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if instanceId == "" || launched != candidates[0] {
+			t.Errorf("expected a launch in %s, got %q in %s", candidates[0], instanceId, launched)
+		}
+	})
+}
+
+func TestInstanceResourceCreateCancelled(t *testing.T) {
+	ctx := context.Background()
+
+	fake := fakelambda.New(fakelambda.Options{})
+	if _, err := fake.AddSshKey("cancel"); err != nil {
+		t.Fatal(err)
+	}
+	fake.InjectFault(fakelambda.Fault{
+		Method:     http.MethodPost,
+		PathPrefix: "/api/v1/instance-operations/launch",
+		Latency:    300 * time.Millisecond,
+	})
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client := &ProviderConfig{ApiKey: "test", Endpoint: server.URL, HTTPClient: &http.Client{}}
+	client.launches = newLaunchBatcher(100*time.Millisecond, client)
+	r := &InstanceResource{client: client}
+
+	var schemaResp fwresource.SchemaResponse
+	r.Schema(ctx, fwresource.SchemaRequest{}, &schemaResp)
+	schemaType := schemaResp.Schema.Type().TerraformType(ctx)
+
+	plan := tfsdk.Plan{
+		Schema: schemaResp.Schema,
+		Raw: testObjectValue(schemaType, map[string]tftypes.Value{
+			"name":               tftypes.NewValue(tftypes.String, "cancelled"),
+			"region_name":        tftypes.NewValue(tftypes.String, "us-east-1"),
+			"instance_type_name": tftypes.NewValue(tftypes.String, "gpu_1x_a10"),
+			"ssh_key_names": tftypes.NewValue(tftypes.Set{ElementType: tftypes.String}, []tftypes.Value{
+				tftypes.NewValue(tftypes.String, "cancel"),
+			}),
+			"id":     tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+			"ip":     tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+			"status": tftypes.NewValue(tftypes.String, tftypes.UnknownValue),
+		}),
+	}
+	resp := &fwresource.CreateResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(schemaType, nil)}}
+
+	// The run is cancelled while the batch, sent after 100ms, is launching
+	cancelledCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	r.Create(cancelledCtx, fwresource.CreateRequest{Plan: plan}, resp)
+
+	if got := errorSummaries(resp.Diagnostics); got != "Client Error" {
+		t.Fatalf("expected the read after launching to fail, got %v", resp.Diagnostics)
+	}
+
+	var state InstanceModel
+	if diags := resp.State.Get(ctx, &state); diags.HasError() {
+		t.Fatal(diags)
+	}
+	instances := fake.Instances()
+	if len(instances) != 1 || state.Id.ValueString() != instances[0].Id {
+		t.Fatalf("expected the launched instance in state, got %s for %v", state.Id, instances)
+	}
+	if !state.Ip.IsNull() || !state.Status.IsNull() {
+		t.Errorf("expected attributes that were not read to be null, got ip %s and status %s", state.Ip, state.Status)
+	}
+}
+
+func TestEstimatedCostCents(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+
+	for name, tc := range map[string]struct {
+		launchedAt types.String
+		price      int64
+		want       types.Int64
+	}{
+		"null launched_at": {
+			launchedAt: types.StringNull(),
+			price:      75,
+			want:       types.Int64Null(),
+		},
+		"unknown launched_at": {
+			launchedAt: types.StringUnknown(),
+			price:      75,
+			want:       types.Int64Null(),
+		},
+		"unparsable launched_at": {
+			launchedAt: types.StringValue("yesterday"),
+			price:      75,
+			want:       types.Int64Null(),
+		},
+		"launched_at in the future from clock skew": {
+			launchedAt: types.StringValue("2026-01-02T15:00:05Z"),
+			price:      75,
+			want:       types.Int64Null(),
+		},
+		"just launched": {
+			launchedAt: types.StringValue("2026-01-02T15:00:00Z"),
+			price:      75,
+			want:       types.Int64Value(0),
+		},
+		"whole hours": {
+			launchedAt: types.StringValue("2026-01-02T03:00:00Z"),
+			price:      129,
+			want:       types.Int64Value(1548),
+		},
+		"rounds down below half a cent": {
+			// 20 minutes at $0.76/hr is 25.33 cents
+			launchedAt: types.StringValue("2026-01-02T14:40:00Z"),
+			price:      76,
+			want:       types.Int64Value(25),
+		},
+		"rounds up from half a cent": {
+			// 30 minutes at $0.75/hr is 37.5 cents
+			launchedAt: types.StringValue("2026-01-02T14:30:00Z"),
+			price:      75,
+			want:       types.Int64Value(38),
+		},
+		"launch time in another zone": {
+			launchedAt: types.StringValue("2026-01-02T09:00:00-05:00"),
+			price:      100,
+			want:       types.Int64Value(100),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if got := estimatedCostCents(tc.launchedAt, tc.price, now); !got.Equal(tc.want) {
+				t.Errorf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestAccInstanceResource_batchedLaunch(t *testing.T) {
+	env := testAccPreCheck(t)
+	env.skipUnlessFake(t)
+	name := testAccResourcePrefix + acctest.RandString(8)
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckInstanceDestroy(env),
+		Steps: []resource.TestStep{
+			{
+				Config: env.providerConfig() + fmt.Sprintf(`
+resource "lambda_instance" "test" {
+  count = 4
+
+  name               = %[1]q
+  region_name        = %[2]q
+  instance_type_name = %[3]q
+  ssh_key_names      = [%[4]q]
+}
+`, name, env.RegionName, env.InstanceTypeName, env.SshKeyName),
+				Check: func(*terraform.State) error {
+					if got := countLaunchRequests(env.Fake); got != 1 {
+						return fmt.Errorf("expected the 4 instances to be launched in one call, got %d calls", got)
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestAccInstanceResource_reorderedSshKeys(t *testing.T) {
+	env := testAccPreCheck(t)
+	env.skipUnlessFake(t)
+	name := testAccResourcePrefix + acctest.RandString(8)
+
+	otherKeyName := testAccResourcePrefix + acctest.RandString(8)
+	if _, err := env.Fake.AddSshKey(otherKeyName); err != nil {
+		t.Fatal(err)
+	}
+
+	config := func(sshKeyNames ...string) string {
+		return env.providerConfig() + fmt.Sprintf(`
+resource "lambda_instance" "test" {
+  name               = %[1]q
+  region_name        = %[2]q
+  instance_type_name = %[3]q
+  ssh_key_names      = [%[4]q, %[5]q]
+}
+`, name, env.RegionName, env.InstanceTypeName, sshKeyNames[0], sshKeyNames[1])
+	}
+
+	resource.Test(t, resource.TestCase{
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy:             testAccCheckInstanceDestroy(env),
+		Steps: []resource.TestStep{
+			{
+				Config: config(env.SshKeyName, otherKeyName),
+			},
+			// Reordering the keys must not replace the instance
+			{
+				Config: config(otherKeyName, env.SshKeyName),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+		},
+	})
+}
+
+func testAccInstanceResourceConfig(env *testAccEnv, name string) string {
+	return env.providerConfig() + fmt.Sprintf(`
+resource "lambda_instance" "test" {
+  name               = %[1]q
+  region_name        = %[2]q
+  instance_type_name = %[3]q
+  ssh_key_names      = [%[4]q]
+}
+`, name, env.RegionName, env.InstanceTypeName, env.SshKeyName)
+}
+
+// testAccCaptureAttr stores the value of a resource attribute for later steps.
+func testAccCaptureAttr(resourceName, attr string, target *string) resource.TestCheckFunc {
+	return resource.TestCheckResourceAttrWith(resourceName, attr, func(value string) error {
+		*target = value
+		return nil
+	})
+}
+
+// testAccCheckInstanceDestroy checks that every instance in state is gone or
+// on its way out.
+func testAccCheckInstanceDestroy(env *testAccEnv) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		client := env.client()
+
+		for _, rs := range s.RootModule().Resources {
+			if rs.Type != "lambda_instance" {
+				continue
+			}
+
+			instance, err := client.GetInstance(context.Background(), rs.Primary.ID)
+			if isNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if instance.Status != "terminating" && instance.Status != "terminated" {
+				return fmt.Errorf("instance %s still exists with status %s", rs.Primary.ID, instance.Status)
+			}
+		}
+
+		return nil
+	}
+}
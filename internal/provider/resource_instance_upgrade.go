@@ -0,0 +1,163 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// instanceSchemaVersion is the version of the lambda_instance schema. Bump it
+// whenever an attribute changes type or shape, freeze the schema it replaces
+// below, and add an upgrader from that version to UpgradeState.
+//
+// Version 0 is the schema of releases before the schema was versioned.
+// Version 1 has the same attributes and starts versioning.
+// Version 2 turns ssh_key_names and file_system_names from lists into sets.
+const instanceSchemaVersion = 2
+
+// UpgradeState upgrades state written by every prior schema version straight
+// to the current one.
+func (r *InstanceResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	schemaV0 := instanceSchemaV0()
+
+	return map[int64]resource.StateUpgrader{
+		0: {
+			PriorSchema:   &schemaV0,
+			StateUpgrader: upgradeInstanceStateV0,
+		},
+		1: {
+			PriorSchema:   &schemaV0,
+			StateUpgrader: upgradeInstanceStateV0,
+		},
+	}
+}
+
+// instanceModelV0 is the lambda_instance state of schema versions 0 and 1.
+// State from releases that predate some of these attributes reads them as
+// null.
+type instanceModelV0 struct {
+	Id               types.String `tfsdk:"id"`
+	Name             types.String `tfsdk:"name"`
+	RegionName       types.String `tfsdk:"region_name"`
+	InstanceTypeName types.String `tfsdk:"instance_type_name"`
+	LaunchCandidates types.List   `tfsdk:"launch_candidates"`
+	WaitForCapacity  types.Object `tfsdk:"wait_for_capacity"`
+	SshKeyNames      types.List   `tfsdk:"ssh_key_names"`
+	FileSystemNames  types.List   `tfsdk:"file_system_names"`
+	UserData         types.String `tfsdk:"user_data"`
+	Ip               types.String `tfsdk:"ip"`
+	PrivateIp        types.String `tfsdk:"private_ip"`
+	Hostname         types.String `tfsdk:"hostname"`
+	Status           types.String `tfsdk:"status"`
+
+	PriceCentsPerHour  types.Int64  `tfsdk:"price_cents_per_hour"`
+	LaunchedAt         types.String `tfsdk:"launched_at"`
+	EstimatedCostCents types.Int64  `tfsdk:"estimated_cost_cents"`
+}
+
+// instanceSchemaV0 is the lambda_instance schema of versions 0 and 1, frozen.
+// Only the attribute types matter for reading prior state.
+func instanceSchemaV0() schema.Schema {
+	return schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id":                 schema.StringAttribute{Computed: true},
+			"name":               schema.StringAttribute{Optional: true},
+			"region_name":        schema.StringAttribute{Optional: true, Computed: true},
+			"instance_type_name": schema.StringAttribute{Optional: true, Computed: true},
+			"launch_candidates": schema.ListNestedAttribute{
+				Optional: true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"instance_type_name": schema.StringAttribute{Required: true},
+						"region_name":        schema.StringAttribute{Required: true},
+					},
+				},
+			},
+			"wait_for_capacity": schema.SingleNestedAttribute{
+				Optional: true,
+				Attributes: map[string]schema.Attribute{
+					"timeout":       schema.StringAttribute{Required: true},
+					"poll_interval": schema.StringAttribute{Optional: true},
+				},
+			},
+			"ssh_key_names":        schema.ListAttribute{ElementType: types.StringType, Required: true},
+			"file_system_names":    schema.ListAttribute{ElementType: types.StringType, Optional: true},
+			"user_data":            schema.StringAttribute{Optional: true},
+			"ip":                   schema.StringAttribute{Computed: true},
+			"private_ip":           schema.StringAttribute{Computed: true},
+			"hostname":             schema.StringAttribute{Computed: true},
+			"status":               schema.StringAttribute{Computed: true},
+			"price_cents_per_hour": schema.Int64Attribute{Computed: true},
+			"launched_at":          schema.StringAttribute{Computed: true},
+			"estimated_cost_cents": schema.Int64Attribute{Computed: true},
+		},
+	}
+}
+
+// upgradeInstanceStateV0 upgrades state of version 0 or 1, which share their
+// attributes, by turning the SSH key and file system lists into sets.
+func upgradeInstanceStateV0(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+	var prior instanceModelV0
+	resp.Diagnostics.Append(req.State.Get(ctx, &prior)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	upgraded := InstanceModel{
+		Id:                 prior.Id,
+		Name:               prior.Name,
+		RegionName:         prior.RegionName,
+		InstanceTypeName:   prior.InstanceTypeName,
+		LaunchCandidates:   prior.LaunchCandidates,
+		WaitForCapacity:    prior.WaitForCapacity,
+		UserData:           prior.UserData,
+		Ip:                 prior.Ip,
+		PrivateIp:          prior.PrivateIp,
+		Hostname:           prior.Hostname,
+		Status:             prior.Status,
+		PriceCentsPerHour:  prior.PriceCentsPerHour,
+		LaunchedAt:         prior.LaunchedAt,
+		EstimatedCostCents: prior.EstimatedCostCents,
+	}
+
+	var diags diag.Diagnostics
+	upgraded.SshKeyNames, diags = stringListToSet(ctx, prior.SshKeyNames)
+	resp.Diagnostics.Append(diags...)
+	upgraded.FileSystemNames, diags = stringListToSet(ctx, prior.FileSystemNames)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &upgraded)...)
+}
+
+// stringListToSet converts a list of strings in prior state to a set. Repeated
+// strings, which a set cannot hold, are kept once.
+func stringListToSet(ctx context.Context, list types.List) (types.Set, diag.Diagnostics) {
+	if list.IsNull() {
+		return types.SetNull(types.StringType), nil
+	}
+
+	var values []string
+	diags := list.ElementsAs(ctx, &values, false)
+	if diags.HasError() {
+		return types.SetNull(types.StringType), diags
+	}
+
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+
+	set, setDiags := types.SetValueFrom(ctx, types.StringType, unique)
+	diags.Append(setDiags...)
+	return set, diags
+}
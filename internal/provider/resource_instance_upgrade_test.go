@@ -0,0 +1,152 @@
+package provider
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+)
+
+// upgradeTestState upgrades rawState, written at version, through the
+// provider server as Terraform would, and reads the result into target.
+func upgradeTestState(t *testing.T, r resource.Resource, typeName string, version int64, rawState string, target interface{}) {
+	t.Helper()
+	ctx := context.Background()
+
+	server, err := providerserver.NewProtocol6WithError(New("test")())()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := server.UpgradeResourceState(ctx, &tfprotov6.UpgradeResourceStateRequest{
+		TypeName: typeName,
+		Version:  version,
+		RawState: &tfprotov6.RawState{JSON: []byte(rawState)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range resp.Diagnostics {
+		if d.Severity == tfprotov6.DiagnosticSeverityError {
+			t.Fatalf("%s: %s", d.Summary, d.Detail)
+		}
+	}
+
+	var schemaResp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &schemaResp)
+
+	value, err := resp.UpgradedState.Unmarshal(schemaResp.Schema.Type().TerraformType(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := tfsdk.State{Schema: schemaResp.Schema, Raw: value}
+	if diags := state.Get(ctx, target); diags.HasError() {
+		t.Fatalf("unable to read upgraded state: %v", diags)
+	}
+}
+
+func TestInstanceResourceUpgradeState(t *testing.T) {
+	t.Run("version 0 state from before launch candidates", func(t *testing.T) {
+		var upgraded InstanceModel
+		upgradeTestState(t, NewInstanceResource(), "lambda_instance", 0, `{
+  "id": "0920582c7ff041399e34823a0be62549",
+  "name": "training",
+  "region_name": "us-east-1",
+  "instance_type_name": "gpu_1x_a10",
+  "ssh_key_names": ["deploy"],
+  "file_system_names": null,
+  "ip": "198.51.100.2",
+  "private_ip": "10.0.2.100",
+  "hostname": "198-51-100-2.cloud.lambdalabs.com",
+  "status": "active"
+}`, &upgraded)
+
+		if got := upgraded.Id.ValueString(); got != "0920582c7ff041399e34823a0be62549" {
+			t.Errorf("expected the ID to carry over, got %q", got)
+		}
+		if got := upgraded.InstanceTypeName.ValueString(); got != "gpu_1x_a10" {
+			t.Errorf("expected the instance type to carry over, got %q", got)
+		}
+		if got := upgraded.Status.ValueString(); got != "active" {
+			t.Errorf("expected the status to carry over, got %q", got)
+		}
+		if len(upgraded.SshKeyNames.Elements()) != 1 {
+			t.Errorf("expected one SSH key name, got %s", upgraded.SshKeyNames)
+		}
+		if !upgraded.LaunchCandidates.IsNull() || !upgraded.LaunchedAt.IsNull() || !upgraded.PriceCentsPerHour.IsNull() {
+			t.Error("expected attributes the state predates to be null")
+		}
+	})
+
+	t.Run("version 1 state with every attribute", func(t *testing.T) {
+		var upgraded InstanceModel
+		upgradeTestState(t, NewInstanceResource(), "lambda_instance", 1, `{
+  "id": "0920582c7ff041399e34823a0be62549",
+  "name": "training",
+  "region_name": "us-west-1",
+  "instance_type_name": "gpu_1x_a100",
+  "launch_candidates": [
+    {"instance_type_name": "gpu_1x_h100_pcie", "region_name": "us-east-1"},
+    {"instance_type_name": "gpu_1x_a100", "region_name": "us-west-1"}
+  ],
+  "wait_for_capacity": {"timeout": "2h", "poll_interval": null},
+  "ssh_key_names": ["deploy", "ci"],
+  "file_system_names": ["datasets"],
+  "user_data": "#cloud-config\n",
+  "ip": "198.51.100.2",
+  "private_ip": "10.0.2.100",
+  "hostname": "198-51-100-2.cloud.lambdalabs.com",
+  "status": "active",
+  "price_cents_per_hour": 129,
+  "launched_at": "2026-01-02T03:04:05Z",
+  "estimated_cost_cents": 3096
+}`, &upgraded)
+
+		if got := len(upgraded.LaunchCandidates.Elements()); got != 2 {
+			t.Errorf("expected 2 launch candidates, got %d", got)
+		}
+		if upgraded.WaitForCapacity.IsNull() {
+			t.Error("expected wait_for_capacity to carry over")
+		}
+		if got := len(upgraded.FileSystemNames.Elements()); got != 1 {
+			t.Errorf("expected 1 file system name, got %d", got)
+		}
+		if got := len(upgraded.SshKeyNames.Elements()); got != 2 {
+			t.Errorf("expected 2 SSH key names, got %d", got)
+		}
+		if got := upgraded.PriceCentsPerHour.ValueInt64(); got != 129 {
+			t.Errorf("expected the price to carry over, got %d", got)
+		}
+		if got := upgraded.LaunchedAt.ValueString(); got != "2026-01-02T03:04:05Z" {
+			t.Errorf("expected launched_at to carry over, got %q", got)
+		}
+	})
+
+	t.Run("repeated SSH key names are kept once", func(t *testing.T) {
+		var upgraded InstanceModel
+		upgradeTestState(t, NewInstanceResource(), "lambda_instance", 1, `{
+  "id": "0920582c7ff041399e34823a0be62549",
+  "region_name": "us-east-1",
+  "instance_type_name": "gpu_1x_a10",
+  "ssh_key_names": ["deploy", "ci", "deploy"],
+  "status": "active"
+}`, &upgraded)
+
+		var sshKeyNames []string
+		if diags := upgraded.SshKeyNames.ElementsAs(context.Background(), &sshKeyNames, false); diags.HasError() {
+			t.Fatal(diags)
+		}
+		sort.Strings(sshKeyNames)
+		if len(sshKeyNames) != 2 || sshKeyNames[0] != "ci" || sshKeyNames[1] != "deploy" {
+			t.Errorf("expected ci and deploy, got %v", sshKeyNames)
+		}
+		if !upgraded.FileSystemNames.IsNull() {
+			t.Errorf("expected no file system names, got %s", upgraded.FileSystemNames)
+		}
+	})
+}
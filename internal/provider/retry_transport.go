@@ -0,0 +1,170 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	defaultRetryBaseDelay = time.Second
+	defaultRetryMaxDelay  = 30 * time.Second
+)
+
+// retryTransport gives every attempt of a call its own timeout and retries
+// failed attempts with exponential backoff. Rate limiting is retried for any
+// request since the API rejected it before acting on it; server and network
+// errors only for idempotent methods, so that a launch is never repeated.
+type retryTransport struct {
+	next       http.RoundTripper
+	maxRetries int
+	timeout    time.Duration
+
+	// baseDelay is the backoff before the first retry, doubling after each
+	// one up to maxDelay. A Retry-After header takes precedence but is also
+	// capped at maxDelay.
+	baseDelay time.Duration
+	maxDelay  time.Duration
+}
+
+func newRetryTransport(next http.RoundTripper, maxRetries int, timeout time.Duration) *retryTransport {
+	return &retryTransport{
+		next:       next,
+		maxRetries: maxRetries,
+		timeout:    timeout,
+		baseDelay:  defaultRetryBaseDelay,
+		maxDelay:   defaultRetryMaxDelay,
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	// A body that cannot be replayed allows a single attempt only
+	maxRetries := t.maxRetries
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		maxRetries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptReq, cancel, err := t.attemptRequest(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := t.next.RoundTrip(attemptReq)
+
+		if attempt >= maxRetries || !retryable(req.Method, resp, err) || ctx.Err() != nil {
+			if resp == nil {
+				cancel()
+				return nil, err
+			}
+			// The attempt's timeout covers reading the body as well
+			resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+			return resp, err
+		}
+
+		delay := t.backoff(attempt, resp)
+		fields := map[string]interface{}{
+			"method":   req.Method,
+			"path":     req.URL.Path,
+			"attempt":  attempt + 1,
+			"delay_ms": delay.Milliseconds(),
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+		} else {
+			fields["status"] = resp.StatusCode
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		cancel()
+
+		tflog.Debug(ctx, "Retrying API request", fields)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attemptRequest returns a copy of req for one attempt, with a fresh body, the
+// retry count for logging and the per-attempt timeout.
+func (t *retryTransport) attemptRequest(req *http.Request, attempt int) (*http.Request, context.CancelFunc, error) {
+	ctx := withRetryCount(req.Context(), attempt)
+
+	cancel := context.CancelFunc(func() {})
+	if t.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, t.timeout)
+	}
+
+	attemptReq := req.Clone(ctx)
+	if attempt > 0 && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, nil, err
+		}
+		attemptReq.Body = body
+	}
+
+	return attemptReq, cancel, nil
+}
+
+// backoff returns how long to wait before retrying after the given attempt.
+func (t *retryTransport) backoff(attempt int, resp *http.Response) time.Duration {
+	delay := t.baseDelay << attempt
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			delay = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if delay > t.maxDelay || delay < 0 {
+		delay = t.maxDelay
+	}
+	return delay
+}
+
+// retryable reports whether a failed attempt should be retried.
+func retryable(method string, resp *http.Response, err error) bool {
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// cancelOnClose releases an attempt's context once its response body is
+// closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
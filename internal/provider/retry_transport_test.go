@@ -0,0 +1,123 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/albertocavalcante/terraform-provider-lambda/internal/fakelambda"
+)
+
+// newRetryTestClient returns a client for fake that retries without waiting.
+func newRetryTestClient(fake *fakelambda.Server, maxRetries int, timeout time.Duration) (*ProviderConfig, func()) {
+	server := httptest.NewServer(fake)
+
+	transport := newRetryTransport(http.DefaultTransport, maxRetries, timeout)
+	transport.baseDelay = time.Millisecond
+	transport.maxDelay = 10 * time.Millisecond
+
+	return &ProviderConfig{
+		ApiKey:     "test",
+		Endpoint:   server.URL,
+		HTTPClient: &http.Client{Transport: transport},
+	}, server.Close
+}
+
+func TestRetryTransport(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rate limited requests are retried", func(t *testing.T) {
+		fake := fakelambda.New(fakelambda.Options{})
+		fake.InjectFault(fakelambda.RateLimitFault(2, time.Second))
+		client, closeServer := newRetryTestClient(fake, 3, time.Minute)
+		defer closeServer()
+
+		if _, err := client.CreateSshKey(ctx, CreateSshKeyRequest{Name: "retried"}); err != nil {
+			t.Fatal(err)
+		}
+		if got := len(fake.Requests()); got != 3 {
+			t.Errorf("expected 3 attempts, got %d", got)
+		}
+		if keys := fake.SshKeys(); len(keys) != 1 {
+			t.Errorf("expected the key to be created once, got %d keys", len(keys))
+		}
+	})
+
+	t.Run("server errors are retried for idempotent requests", func(t *testing.T) {
+		fake := fakelambda.New(fakelambda.Options{})
+		fake.InjectFault(fakelambda.ServerErrorFault(2, http.StatusServiceUnavailable))
+		client, closeServer := newRetryTestClient(fake, 3, time.Minute)
+		defer closeServer()
+
+		if _, err := client.ListSshKeys(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if got := len(fake.Requests()); got != 3 {
+			t.Errorf("expected 3 attempts, got %d", got)
+		}
+	})
+
+	t.Run("server errors are not retried for launches", func(t *testing.T) {
+		fake := fakelambda.New(fakelambda.Options{})
+		fake.InjectFault(fakelambda.ServerErrorFault(1, http.StatusInternalServerError))
+		client, closeServer := newRetryTestClient(fake, 3, time.Minute)
+		defer closeServer()
+
+		_, err := client.LaunchInstances(ctx, LaunchRequest{
+			RegionName:       "us-east-1",
+			InstanceTypeName: "gpu_1x_a10",
+		})
+		if err == nil {
+			t.Fatal("expected the launch to fail")
+		}
+		if got := len(fake.Requests()); got != 1 {
+			t.Errorf("expected a single attempt, got %d", got)
+		}
+	})
+
+	t.Run("retries are limited", func(t *testing.T) {
+		fake := fakelambda.New(fakelambda.Options{})
+		fake.InjectFault(fakelambda.ServerErrorFault(0, http.StatusBadGateway))
+		client, closeServer := newRetryTestClient(fake, 2, time.Minute)
+		defer closeServer()
+
+		if _, err := client.ListSshKeys(ctx); err == nil {
+			t.Fatal("expected the request to fail")
+		}
+		if got := len(fake.Requests()); got != 3 {
+			t.Errorf("expected 3 attempts, got %d", got)
+		}
+	})
+
+	t.Run("each attempt has its own timeout", func(t *testing.T) {
+		fake := fakelambda.New(fakelambda.Options{})
+		fake.InjectFault(fakelambda.Fault{Latency: time.Second, Times: 1})
+		client, closeServer := newRetryTestClient(fake, 1, 100*time.Millisecond)
+		defer closeServer()
+
+		if _, err := client.ListSshKeys(ctx); err != nil {
+			t.Fatalf("expected the second attempt to succeed, got %s", err)
+		}
+	})
+}
+
+func TestRetryTransportBackoff(t *testing.T) {
+	transport := newRetryTransport(http.DefaultTransport, 3, time.Minute)
+
+	if got := transport.backoff(0, nil); got != time.Second {
+		t.Errorf("expected 1s before the first retry, got %s", got)
+	}
+	if got := transport.backoff(2, nil); got != 4*time.Second {
+		t.Errorf("expected 4s before the third retry, got %s", got)
+	}
+	if got := transport.backoff(10, nil); got != defaultRetryMaxDelay {
+		t.Errorf("expected the delay to be capped at %s, got %s", defaultRetryMaxDelay, got)
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"7"}}}
+	if got := transport.backoff(0, resp); got != 7*time.Second {
+		t.Errorf("expected Retry-After to be honoured, got %s", got)
+	}
+}
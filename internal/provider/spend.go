@@ -0,0 +1,143 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// spendTracker adds up the hourly cost that the changes planned by this
+// provider instance add. Terraform configures a fresh provider for each plan,
+// so the total covers a single run.
+type spendTracker struct {
+	mu           sync.Mutex
+	plannedCents int64
+}
+
+// add records cents per hour of planned spend and returns the new total.
+func (t *spendTracker) add(cents int64) int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.plannedCents += cents
+	return t.plannedCents
+}
+
+// plannedSpend describes the instances a resource plans to run.
+type plannedSpend struct {
+	// InstanceTypeNames are the instance types that may be launched. When
+	// there are several candidates, the most expensive one is assumed.
+	InstanceTypeNames []string
+	Count             int64
+
+	// PriorInstanceTypeName and PriorCount describe what is running today,
+	// and are empty when the resource is being created.
+	PriorInstanceTypeName string
+	PriorCount            int64
+
+	// Path is the attribute that diagnostics point at.
+	Path path.Path
+}
+
+// checkSpend prices a planned change using the instance types API and
+// enforces max_instance_price_cents_per_hour and max_hourly_spend_cents. Only
+// the spend a change adds over what is running today counts towards
+// max_hourly_spend_cents, and gets a warning stating how much.
+func (c *ProviderConfig) checkSpend(ctx context.Context, planned plannedSpend) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	limited := c.MaxHourlySpendCents > 0 || c.MaxInstancePriceCentsPerHour > 0
+
+	instanceTypes, err := c.instanceTypes(ctx)
+	if err != nil {
+		summary, detail := "Unable to check hourly spend", fmt.Sprintf("Unable to read instance type prices, got error: %s", err)
+		if limited {
+			diags.AddAttributeError(planned.Path, summary, detail)
+		} else {
+			diags.AddAttributeWarning(planned.Path, summary, detail)
+		}
+		return diags
+	}
+
+	var priceCents int64
+	var pricedName string
+	for _, name := range planned.InstanceTypeNames {
+		instanceType, ok := instanceTypes[name]
+		if ok && instanceType.InstanceType.PriceCentsPerHour >= priceCents {
+			priceCents = instanceType.InstanceType.PriceCentsPerHour
+			pricedName = name
+		}
+	}
+
+	if pricedName == "" {
+		diags.AddAttributeWarning(
+			planned.Path,
+			"Unable to check hourly spend",
+			fmt.Sprintf("No price is listed for instance type %v, so its cost is not counted towards the spend limits.", planned.InstanceTypeNames),
+		)
+		return diags
+	}
+
+	costCents := priceCents * planned.Count
+
+	var priorCostCents int64
+	if prior, ok := instanceTypes[planned.PriorInstanceTypeName]; ok {
+		priorCostCents = prior.InstanceType.PriceCentsPerHour * planned.PriorCount
+	}
+
+	if c.MaxInstancePriceCentsPerHour > 0 && priceCents > c.MaxInstancePriceCentsPerHour {
+		diags.AddAttributeError(
+			planned.Path,
+			"Instance price exceeds limit",
+			fmt.Sprintf("Instance type %s costs %s/hr, which exceeds the provider's max_instance_price_cents_per_hour of %s/hr.",
+				pricedName, formatCents(priceCents), formatCents(c.MaxInstancePriceCentsPerHour)),
+		)
+	}
+
+	addedCents := costCents - priorCostCents
+	if addedCents <= 0 {
+		return diags
+	}
+
+	totalCents := c.spend.add(addedCents)
+	if c.MaxHourlySpendCents > 0 && totalCents > c.MaxHourlySpendCents {
+		diags.AddAttributeError(
+			planned.Path,
+			"Hourly spend exceeds limit",
+			fmt.Sprintf("This plan adds at least %s/hr in total, which exceeds the provider's max_hourly_spend_cents of %s/hr. "+
+				"This change adds %s/hr of that.",
+				formatCents(totalCents), formatCents(c.MaxHourlySpendCents), formatCents(addedCents)),
+		)
+	}
+
+	diags.AddAttributeWarning(
+		planned.Path,
+		"Plan adds hourly spend",
+		fmt.Sprintf("This change adds %s/hr, bringing it to %d x %s at %s/hr.", formatCents(addedCents), planned.Count, pricedName, formatCents(priceCents)),
+	)
+
+	return diags
+}
+
+// formatCents formats an amount in US cents as dollars, e.g. "$12.34".
+func formatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s$%d.%02d", sign, cents/100, cents%100)
+}
+
+// knownStrings returns the value as a one-element slice, or nothing when it
+// is null or unknown.
+func knownStrings(value types.String) []string {
+	if value.IsNull() || value.IsUnknown() {
+		return nil
+	}
+	return []string{value.ValueString()}
+}
@@ -0,0 +1,151 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// newSpendTestClient returns a client pricing gpu_1x_a10 at $0.75/hr and
+// gpu_8x_h100_sxm5 at $23.92/hr.
+func newSpendTestClient(maxHourlySpendCents, maxInstancePriceCents int64) *ProviderConfig {
+	return &ProviderConfig{
+		MaxHourlySpendCents:          maxHourlySpendCents,
+		MaxInstancePriceCentsPerHour: maxInstancePriceCents,
+		spend:                        &spendTracker{},
+		snapshot: &CatalogSnapshot{
+			InstanceTypes: map[string]InstanceTypeAPIResponse{
+				"gpu_1x_a10":       {InstanceType: InstanceTypeDetails{Name: "gpu_1x_a10", PriceCentsPerHour: 75}},
+				"gpu_8x_h100_sxm5": {InstanceType: InstanceTypeDetails{Name: "gpu_8x_h100_sxm5", PriceCentsPerHour: 2392}},
+			},
+		},
+	}
+}
+
+// diagnosticSummaries returns the summaries of the diagnostics of a severity.
+func diagnosticSummaries(diags diag.Diagnostics, severity diag.Severity) []string {
+	var summaries []string
+	for _, d := range diags {
+		if d.Severity() == severity {
+			summaries = append(summaries, d.Summary())
+		}
+	}
+	return summaries
+}
+
+func TestCheckSpend(t *testing.T) {
+	for name, tc := range map[string]struct {
+		maxHourlySpendCents   int64
+		maxInstancePriceCents int64
+		planned               []plannedSpend
+		wantErrors            []string
+		wantWarnings          []string
+	}{
+		"new instance warns of the added spend": {
+			planned:      []plannedSpend{{InstanceTypeNames: []string{"gpu_1x_a10"}, Count: 1}},
+			wantWarnings: []string{"Plan adds hourly spend"},
+		},
+		"unchanged instance adds nothing": {
+			maxHourlySpendCents: 100,
+			planned: []plannedSpend{
+				{InstanceTypeNames: []string{"gpu_1x_a10"}, Count: 1, PriorInstanceTypeName: "gpu_1x_a10", PriorCount: 1},
+				{InstanceTypeNames: []string{"gpu_1x_a10"}, Count: 1, PriorInstanceTypeName: "gpu_1x_a10", PriorCount: 1},
+			},
+		},
+		"shrinking a group adds nothing": {
+			planned: []plannedSpend{{InstanceTypeNames: []string{"gpu_1x_a10"}, Count: 2, PriorInstanceTypeName: "gpu_1x_a10", PriorCount: 4}},
+		},
+		"running instances do not count towards the limit": {
+			maxHourlySpendCents: 100,
+			planned: []plannedSpend{
+				// Growing a group of 10 by one only adds $0.75/hr
+				{InstanceTypeNames: []string{"gpu_1x_a10"}, Count: 11, PriorInstanceTypeName: "gpu_1x_a10", PriorCount: 10},
+			},
+			wantWarnings: []string{"Plan adds hourly spend"},
+		},
+		"added spend adds up across resources": {
+			maxHourlySpendCents: 100,
+			planned: []plannedSpend{
+				{InstanceTypeNames: []string{"gpu_1x_a10"}, Count: 1},
+				{InstanceTypeNames: []string{"gpu_1x_a10"}, Count: 2},
+			},
+			wantErrors:   []string{"Hourly spend exceeds limit"},
+			wantWarnings: []string{"Plan adds hourly spend", "Plan adds hourly spend"},
+		},
+		"changing the instance type counts the difference": {
+			maxHourlySpendCents: 2400,
+			planned: []plannedSpend{
+				{InstanceTypeNames: []string{"gpu_8x_h100_sxm5"}, Count: 1, PriorInstanceTypeName: "gpu_1x_a10", PriorCount: 1},
+			},
+			wantWarnings: []string{"Plan adds hourly spend"},
+		},
+		"most expensive candidate is assumed": {
+			maxInstancePriceCents: 1000,
+			planned:               []plannedSpend{{InstanceTypeNames: []string{"gpu_1x_a10", "gpu_8x_h100_sxm5"}, Count: 1}},
+			wantErrors:            []string{"Instance price exceeds limit"},
+			wantWarnings:          []string{"Plan adds hourly spend"},
+		},
+		"unpriced instance type": {
+			maxHourlySpendCents: 100,
+			planned:             []plannedSpend{{InstanceTypeNames: []string{"gpu_1x_unknown"}, Count: 1}},
+			wantWarnings:        []string{"Unable to check hourly spend"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			client := newSpendTestClient(tc.maxHourlySpendCents, tc.maxInstancePriceCents)
+
+			var diags diag.Diagnostics
+			for _, planned := range tc.planned {
+				planned.Path = path.Root("instance_type_name")
+				diags.Append(client.checkSpend(context.Background(), planned)...)
+			}
+
+			errors := diagnosticSummaries(diags, diag.SeverityError)
+			if strings.Join(errors, ", ") != strings.Join(tc.wantErrors, ", ") {
+				t.Errorf("expected errors %v, got %v", tc.wantErrors, errors)
+			}
+			warnings := diagnosticSummaries(diags, diag.SeverityWarning)
+			if strings.Join(warnings, ", ") != strings.Join(tc.wantWarnings, ", ") {
+				t.Errorf("expected warnings %v, got %v", tc.wantWarnings, warnings)
+			}
+		})
+	}
+}
+
+func TestCheckSpendReportsAddedCost(t *testing.T) {
+	client := newSpendTestClient(0, 0)
+
+	diags := client.checkSpend(context.Background(), plannedSpend{
+		InstanceTypeNames:     []string{"gpu_1x_a10"},
+		Count:                 3,
+		PriorInstanceTypeName: "gpu_1x_a10",
+		PriorCount:            1,
+		Path:                  path.Root("size"),
+	})
+
+	if len(diags) != 1 {
+		t.Fatalf("expected one warning, got %v", diags)
+	}
+	if want := "This change adds $1.50/hr, bringing it to 3 x gpu_1x_a10 at $0.75/hr."; diags[0].Detail() != want {
+		t.Errorf("expected %q, got %q", want, diags[0].Detail())
+	}
+}
+
+func TestFormatCents(t *testing.T) {
+	for cents, want := range map[int64]string{
+		0:      "$0.00",
+		5:      "$0.05",
+		75:     "$0.75",
+		100:    "$1.00",
+		2392:   "$23.92",
+		123456: "$1234.56",
+		-150:   "-$1.50",
+	} {
+		if got := formatCents(cents); got != want {
+			t.Errorf("formatCents(%d) = %q, want %q", cents, got, want)
+		}
+	}
+}
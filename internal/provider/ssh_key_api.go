@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// SshKey represents an SSH key from the ssh-keys API. PrivateKey is only set
+// in the response to creating a key without a public key, when the API
+// generates the key pair.
+type SshKey struct {
+	Id         string `json:"id"`
+	Name       string `json:"name"`
+	PublicKey  string `json:"public_key"`
+	PrivateKey string `json:"private_key,omitempty"`
+}
+
+// CreateSshKeyRequest represents the request body for adding an SSH key
+type CreateSshKeyRequest struct {
+	Name      string  `json:"name"`
+	PublicKey *string `json:"public_key,omitempty"`
+}
+
+// SshKeyResponse represents the response from the create SSH key API
+type SshKeyResponse struct {
+	Data SshKey `json:"data"`
+}
+
+// SshKeysResponse represents the response from the list SSH keys API
+type SshKeysResponse struct {
+	Data []SshKey `json:"data"`
+}
+
+// CreateSshKey adds an SSH key. Without a public key the API generates a new
+// key pair and returns its private key, which it does not store.
+func (c *ProviderConfig) CreateSshKey(ctx context.Context, createReq CreateSshKeyRequest) (*SshKey, error) {
+	jsonData, err := json.Marshal(createReq)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST",
+		fmt.Sprintf("%s/api/v1/ssh-keys", c.Endpoint),
+		strings.NewReader(string(jsonData)))
+	if err != nil {
+		return nil, err
+	}
+
+	c.AddAuthHeader(httpReq)
+
+	httpResp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := httpResp.Body.Close(); err != nil {
+			tflog.Warn(ctx, "Failed to close response body", map[string]interface{}{"error": err})
+		}
+	}()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, newAPIError("create SSH key", httpResp)
+	}
+
+	var keyResp SshKeyResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&keyResp); err != nil {
+		return nil, err
+	}
+
+	return &keyResp.Data, nil
+}
+
+// ListSshKeys lists the SSH keys of the account
+func (c *ProviderConfig) ListSshKeys(ctx context.Context) ([]SshKey, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET",
+		fmt.Sprintf("%s/api/v1/ssh-keys", c.Endpoint), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c.AddAuthHeader(httpReq)
+
+	httpResp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := httpResp.Body.Close(); err != nil {
+			tflog.Warn(ctx, "Failed to close response body", map[string]interface{}{"error": err})
+		}
+	}()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, newAPIError("list SSH keys", httpResp)
+	}
+
+	var keysResp SshKeysResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&keysResp); err != nil {
+		return nil, err
+	}
+
+	return keysResp.Data, nil
+}
+
+// DeleteSshKey deletes an SSH key by ID
+func (c *ProviderConfig) DeleteSshKey(ctx context.Context, sshKeyId string) error {
+	httpReq, err := http.NewRequestWithContext(ctx, "DELETE",
+		fmt.Sprintf("%s/api/v1/ssh-keys/%s", c.Endpoint, url.PathEscape(sshKeyId)), nil)
+	if err != nil {
+		return err
+	}
+
+	c.AddAuthHeader(httpReq)
+
+	httpResp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := httpResp.Body.Close(); err != nil {
+			tflog.Warn(ctx, "Failed to close response body", map[string]interface{}{"error": err})
+		}
+	}()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return newAPIError("delete SSH key", httpResp)
+	}
+
+	return nil
+}
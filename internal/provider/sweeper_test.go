@@ -0,0 +1,102 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+)
+
+// The sweepers clean up resources leaked by failed acceptance test runs
+// against the real API. Run them with:
+//
+//	go test ./internal/provider -v -sweep=all
+//
+// Only resources whose names start with testAccResourcePrefix are removed.
+func init() {
+	resource.AddTestSweepers("lambda_instance", &resource.Sweeper{
+		Name: "lambda_instance",
+		F:    sweepInstances,
+	})
+
+	resource.AddTestSweepers("lambda_ssh_key", &resource.Sweeper{
+		Name:         "lambda_ssh_key",
+		Dependencies: []string{"lambda_instance"},
+		F:            sweepSshKeys,
+	})
+}
+
+// sweeperClient returns a client for the API that the sweepers clean up. The
+// region passed to sweepers is ignored, since the API is global.
+func sweeperClient() (*ProviderConfig, error) {
+	apiKey := os.Getenv("LAMBDA_CLOUD_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("LAMBDA_CLOUD_API_KEY must be set to run sweepers")
+	}
+
+	return &ProviderConfig{
+		ApiKey:     apiKey,
+		Endpoint:   envOrDefault(envEndpoint, defaultEndpoint),
+		HTTPClient: &http.Client{},
+		spend:      &spendTracker{},
+	}, nil
+}
+
+func sweepInstances(_ string) error {
+	ctx := context.Background()
+
+	client, err := sweeperClient()
+	if err != nil {
+		return err
+	}
+
+	instances, err := client.ListInstances(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to list instances: %w", err)
+	}
+
+	var instanceIds []string
+	for _, instance := range instances {
+		if strings.HasPrefix(instance.Name, testAccResourcePrefix) && instance.Status != "terminating" {
+			log.Printf("[INFO] Terminating instance %s (%s)", instance.Id, instance.Name)
+			instanceIds = append(instanceIds, instance.Id)
+		}
+	}
+
+	if len(instanceIds) == 0 {
+		return nil
+	}
+
+	return client.TerminateInstances(ctx, instanceIds)
+}
+
+func sweepSshKeys(_ string) error {
+	ctx := context.Background()
+
+	client, err := sweeperClient()
+	if err != nil {
+		return err
+	}
+
+	sshKeys, err := client.ListSshKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to list SSH keys: %w", err)
+	}
+
+	for _, sshKey := range sshKeys {
+		if !strings.HasPrefix(sshKey.Name, testAccResourcePrefix) {
+			continue
+		}
+
+		log.Printf("[INFO] Deleting SSH key %s (%s)", sshKey.Id, sshKey.Name)
+		if err := client.DeleteSshKey(ctx, sshKey.Id); err != nil && !isNotFound(err) {
+			return fmt.Errorf("unable to delete SSH key %s: %w", sshKey.Id, err)
+		}
+	}
+
+	return nil
+}
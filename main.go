@@ -3,7 +3,9 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"log"
+	"os"
 
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 
@@ -30,6 +32,15 @@ var (
 )
 
 func main() {
+	// Terraform starts the provider without arguments, so a first argument
+	// that is not a flag is a subcommand
+	if len(os.Args) > 1 && os.Args[1] == "snapshot-catalog" {
+		if err := snapshotCatalog(os.Args[2:]); err != nil {
+			log.Fatal(err.Error())
+		}
+		return
+	}
+
 	var debug bool
 
 	flag.BoolVar(&debug, "debug", false, "set to true to run the provider with support for debuggers like delve")
@@ -46,3 +57,30 @@ func main() {
 		log.Fatal(err.Error())
 	}
 }
+
+// snapshotCatalog writes a snapshot of the live instance type, region and
+// image catalog for the catalog_snapshot_file provider attribute:
+//
+//	terraform-provider-lambda snapshot-catalog -output catalog.json
+//
+// The API key and endpoint are found as the provider finds them, for example
+// from LAMBDA_CLOUD_API_KEY and LAMBDA_CLOUD_ENDPOINT.
+func snapshotCatalog(args []string) error {
+	flags := flag.NewFlagSet("snapshot-catalog", flag.ExitOnError)
+	output := flags.String("output", "lambda-catalog.json", "file to write the snapshot to")
+	profile := flags.String("profile", "", "profile of the shared credentials file to use")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	err := provider.WriteCatalogSnapshot(context.Background(), *output, provider.CatalogSnapshotOptions{
+		Version: version,
+		Profile: *profile,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Wrote catalog snapshot to %s\n", *output)
+	return nil
+}
@@ -6,6 +6,16 @@ set -e
 echo "🔧 Building Terraform Provider..."
 go build -o terraform-provider-lambda
 
+echo "🧪 Running unit tests..."
+go test ./...
+
+if command -v terraform >/dev/null 2>&1; then
+    echo "🧪 Running acceptance tests against the local API stand-in..."
+    TF_ACC=1 go test ./internal/provider/ -run '^TestAcc' -count=1
+else
+    echo "⚠️  terraform not found in PATH, skipping acceptance tests"
+fi
+
 echo "📁 Setting up test environment..."
 # Auto-detect platform
 OS=$(uname -s | tr '[:upper:]' '[:lower:]')